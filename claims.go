@@ -1,8 +1,11 @@
 package jwt
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"strings"
 	"time"
 
@@ -13,6 +16,9 @@ import (
 // ErrInvalidClaimType is returned when an operation tries to return an invalid claim type.
 var ErrInvalidClaimType = errors.New("invalid claim type")
 
+// ErrClaimNotFound is returned when a claim is requested that is not present in the token.
+var ErrClaimNotFound = errors.New("claim not found")
+
 const (
 	// Issuer is the IANA Registered claim for JWT issuer.
 	Issuer string = "iss"
@@ -108,6 +114,10 @@ type Claim struct {
 	Float     float64
 	String    string
 	Interface interface{}
+
+	// literal disables the mapping of long names (eg. `subject`) to registered claims,
+	// it is set for claims taken from struct tags.
+	literal bool
 }
 
 // IsRegistered returns true if the Key is a IANA registered "JSON Web Token Claims".
@@ -149,7 +159,15 @@ func (c Claim) Field() string {
 }
 
 // Time returns the time value of the `Claim` or an error if it is not a `TimeType`.
+//
+// Deprecated: use AsTime.
 func (c Claim) Time() (time.Time, error) {
+	return c.AsTime()
+}
+
+// AsTime returns the time value of the `Claim` or an error if it is not a `TimeType`.
+// Times read from a verified token are decoded from a number of seconds.
+func (c Claim) AsTime() (time.Time, error) {
 	if c.Type == TimeType {
 		t := time.Unix(0, c.Integer)
 
@@ -165,6 +183,148 @@ func (c Claim) Time() (time.Time, error) {
 	return time.Time{}, ErrInvalidClaimType
 }
 
+// AsDuration returns the duration value of the `Claim` or an error if it is not a `DurationType`.
+// Durations read from a verified token are decoded from a number of seconds.
+func (c Claim) AsDuration() (time.Duration, error) {
+	if c.Type == DurationType {
+		return time.Duration(c.Integer), nil
+	}
+
+	if c.Type == Float64Type {
+		return time.Duration(c.Float * float64(time.Second)), nil
+	}
+
+	return 0, ErrInvalidClaimType
+}
+
+// AsBinary returns the binary value of the `Claim` or an error if it is not a `BinaryType`.
+// Binary values read from a verified token are decoded from base64url.
+func (c Claim) AsBinary() ([]byte, error) {
+	if c.Type == BinaryType {
+		if b, ok := c.Interface.([]byte); ok {
+			return b, nil
+		}
+
+		return nil, ErrInvalidClaimType
+	}
+
+	if c.Type == StringType {
+		b, err := base64.RawURLEncoding.DecodeString(c.String)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidClaimType, err)
+		}
+
+		return b, nil
+	}
+
+	return nil, ErrInvalidClaimType
+}
+
+// AsString returns the string value of the `Claim` or an error if it is not a `StringType`.
+// `StringerType` and `ErrorType` claims return their string representation.
+func (c Claim) AsString() (string, error) {
+	switch c.Type {
+	case StringType:
+		return c.String, nil
+	case StringerType:
+		if v, ok := c.Interface.(fmt.Stringer); ok && !isNilValue(v) {
+			return v.String(), nil
+		}
+	case ErrorType:
+		if v, ok := c.Interface.(error); ok && !isNilValue(v) {
+			return v.Error(), nil
+		}
+	}
+
+	return "", ErrInvalidClaimType
+}
+
+// AsFloat64 returns the numeric value of the `Claim` or an error if it is not a number.
+func (c Claim) AsFloat64() (float64, error) {
+	switch c.Type {
+	case Float64Type, Float32Type:
+		return c.Float, nil
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		return float64(c.Integer), nil
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type:
+		return float64(c.Uinteger), nil
+	}
+
+	return 0, ErrInvalidClaimType
+}
+
+// AsInt64 returns the integer value of the `Claim` or an error if it is not a whole number.
+func (c Claim) AsInt64() (int64, error) {
+	switch c.Type {
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		return c.Integer, nil
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type:
+		if c.Uinteger <= math.MaxInt64 {
+			return int64(c.Uinteger), nil
+		}
+	case Float64Type, Float32Type:
+		if c.Float == math.Trunc(c.Float) && c.Float >= math.MinInt64 && c.Float < math.MaxInt64 {
+			return int64(c.Float), nil
+		}
+	}
+
+	return 0, ErrInvalidClaimType
+}
+
+// AsBool returns the boolean value of the `Claim` or an error if it is not a `BoolType`.
+func (c Claim) AsBool() (bool, error) {
+	if c.Type == BoolType {
+		if b, ok := c.Interface.(bool); ok {
+			return b, nil
+		}
+	}
+
+	return false, ErrInvalidClaimType
+}
+
+// AsStrings returns the value of the `Claim` as a slice of strings, or an error if
+// it is not a string or an array containing only strings.
+func (c Claim) AsStrings() ([]string, error) {
+	if c.Type == StringType {
+		return []string{c.String}, nil
+	}
+
+	if c.Type != ReflectType {
+		return nil, ErrInvalidClaimType
+	}
+
+	switch val := c.Interface.(type) {
+	case []string:
+		return val, nil
+	case []interface{}:
+		s := make([]string, 0, len(val))
+
+		for _, v := range val {
+			str, ok := v.(string)
+			if !ok {
+				return nil, ErrInvalidClaimType
+			}
+
+			s = append(s, str)
+		}
+
+		return s, nil
+	}
+
+	return nil, ErrInvalidClaimType
+}
+
+// AsMap returns the value of the `Claim` as a map, or an error if it is not a JSON object.
+func (c Claim) AsMap() (map[string]interface{}, error) {
+	if c.Type == ReflectType {
+		if m, ok := c.Interface.(map[string]interface{}); ok {
+			return m, nil
+		}
+	}
+
+	return nil, ErrInvalidClaimType
+}
+
 // String constructs a claim with the given key and value.
 func String(key, val string) Claim {
 	return Claim{Key: key, Type: StringType, String: val}
@@ -205,6 +365,30 @@ func Bool(key string, val bool) Claim {
 	return Claim{Key: key, Type: BoolType, Interface: val}
 }
 
+// Duration constructs a claim with the given key and value.
+// Durations are encoded as a number of seconds.
+func Duration(key string, val time.Duration) Claim {
+	return Claim{Key: key, Type: DurationType, Integer: int64(val)}
+}
+
+// Binary constructs a claim with the given key and value.
+// Binary values are encoded as unpadded base64url.
+func Binary(key string, val []byte) Claim {
+	return Claim{Key: key, Type: BinaryType, Interface: val}
+}
+
+// Stringer constructs a claim with the given key and the output of the value's
+// String method. The value is encoded as a string.
+func Stringer(key string, val fmt.Stringer) Claim {
+	return Claim{Key: key, Type: StringerType, Interface: val}
+}
+
+// NamedError constructs a claim with the given key and the output of the error's
+// Error method. The value is encoded as a string.
+func NamedError(key string, val error) Claim {
+	return Claim{Key: key, Type: ErrorType, Interface: val}
+}
+
 // Reflect constructs a claim with the given key and an arbitrary object. It uses
 // an encoding-appropriate, reflection-based function to lazily serialize nearly
 // any object into the logging context, but it's relatively slow and
@@ -293,8 +477,8 @@ func Any(key string, value interface{}) Claim {
 	// 	return Uint16s(key, val)
 	case uint8:
 		return Uint(key, uint64(val))
-	// case []byte:
-	// 	return Binary(key, val)
+	case []byte:
+		return Binary(key, val)
 	// case uintptr:
 	// 	return Uintptr(key, val)
 	// case []uintptr:
@@ -303,16 +487,16 @@ func Any(key string, value interface{}) Claim {
 		return Time(key, val)
 	// case []time.Time:
 	// 	return Times(key, val)
-	// case time.Duration:
-	// 	return Duration(key, val)
+	case time.Duration:
+		return Duration(key, val)
 	// case []time.Duration:
 	// 	return Durations(key, val)
-	// case error:
-	// 	return NamedError(key, val)
+	case error:
+		return NamedError(key, val)
 	// case []error:
 	// 	return Errors(key, val)
-	// case fmt.Stringer:
-	// 	return Stringer(key, val)
+	case fmt.Stringer:
+		return Stringer(key, val)
 	default:
 		return Reflect(key, val)
 	}
@@ -328,7 +512,7 @@ func ConstructClaimsFromSlice(claims ...Claim) (*jwt.Claims, error) {
 	}
 
 	for _, claim := range claims {
-		if claim.IsRegistered() {
+		if claim.IsRegistered() && (!claim.literal || claim.Key == claim.Field()) {
 			err := constructRegisteredClaim(tokenClaims, claim)
 			if err != nil {
 				return nil, err
@@ -359,7 +543,7 @@ func constructRegisteredClaim(tokenClaims *jwt.Claims, claim Claim) error {
 		tokenClaims.Registered.Audiences = append(tokenClaims.Registered.Audiences, claim.String)
 	case Expires:
 		if claim.Type == TimeType {
-			t, err := claim.Time()
+			t, err := claim.AsTime()
 			if err != nil {
 				return err
 			}
@@ -370,7 +554,7 @@ func constructRegisteredClaim(tokenClaims *jwt.Claims, claim Claim) error {
 		}
 	case NotBefore:
 		if claim.Type == TimeType {
-			t, err := claim.Time()
+			t, err := claim.AsTime()
 			if err != nil {
 				return err
 			}
@@ -381,7 +565,7 @@ func constructRegisteredClaim(tokenClaims *jwt.Claims, claim Claim) error {
 		}
 	case Issued:
 		if claim.Type == TimeType {
-			t, err := claim.Time()
+			t, err := claim.AsTime()
 			if err != nil {
 				return err
 			}
@@ -421,15 +605,60 @@ func constructUnregisteredClaim(tokenClaims *jwt.Claims, claim Claim) error {
 			return fmt.Errorf("bool claim type format incorrect: %s", claim.Key)
 		}
 	case TimeType:
-		t, err := claim.Time()
+		t, err := claim.AsTime()
 		if err != nil {
 			return err
 		}
 
 		tokenClaims.Set[claim.Key] = jwt.NewNumericTime(t)
+	case DurationType:
+		d, err := claim.AsDuration()
+		if err != nil {
+			return err
+		}
+
+		tokenClaims.Set[claim.Key] = d.Seconds()
+	case BinaryType:
+		b, err := claim.AsBinary()
+		if err != nil {
+			return fmt.Errorf("binary claim type format incorrect: %s", claim.Key)
+		}
+
+		tokenClaims.Set[claim.Key] = base64.RawURLEncoding.EncodeToString(b)
+	case StringerType:
+		if v, ok := claim.Interface.(fmt.Stringer); ok && !isNilValue(v) {
+			tokenClaims.Set[claim.Key] = v.String()
+		} else {
+			return fmt.Errorf("stringer claim type format incorrect: %s", claim.Key)
+		}
+	case ErrorType:
+		if v, ok := claim.Interface.(error); ok && !isNilValue(v) {
+			tokenClaims.Set[claim.Key] = v.Error()
+		} else {
+			return fmt.Errorf("error claim type format incorrect: %s", claim.Key)
+		}
+	case ReflectType:
+		tokenClaims.Set[claim.Key] = claim.Interface
 	default:
 		return fmt.Errorf("unsupported claim type: %d", claim.Type)
 	}
 
 	return nil
 }
+
+// isNilValue returns true if the value is nil, or an interface holding a nil pointer, map, slice,
+// channel or function (eg. a `(*T)(nil)` passed as a `fmt.Stringer`).
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
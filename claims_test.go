@@ -0,0 +1,146 @@
+package jwt_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// nilStringer is a `fmt.Stringer` that dereferences its receiver.
+type nilStringer struct {
+	name string
+}
+
+func (s *nilStringer) String() string {
+	return s.name
+}
+
+var _ = Describe("JWT Claims", func() {
+	var signer jwt.Signer
+	var verifier jwt.Verifier
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createVerifier()
+	})
+
+	It("should construct claims from Any", func() {
+		Expect(jwt.Any("custom", 90*time.Second)).To(Equal(jwt.Duration("custom", 90*time.Second)))
+		Expect(jwt.Any("custom", []byte("binary"))).To(Equal(jwt.Binary("custom", []byte("binary"))))
+		Expect(jwt.Any("custom", net.IPv4(127, 0, 0, 1)).Type).To(Equal(jwt.StringerType))
+		Expect(jwt.Any("custom", errors.New("failure")).Type).To(Equal(jwt.ErrorType))
+	})
+
+	It("should succeed, claim:custom(duration type)", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Duration("custom", 90*time.Second),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims["custom"]).To(ContainElement(jwt.Float("custom", 90)))
+		Expect(result.Claims["custom"][0].AsDuration()).To(Equal(90 * time.Second))
+	})
+
+	It("should succeed, claim:custom(binary type)", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Binary("custom", []byte{0xfb, 0xff, 0x00}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims["custom"]).To(ContainElement(jwt.String("custom", "-_8A")))
+		Expect(result.Claims["custom"][0].AsBinary()).To(Equal([]byte{0xfb, 0xff, 0x00}))
+	})
+
+	It("should succeed, claim:custom(stringer type)", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Stringer("custom", net.IPv4(127, 0, 0, 1)),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims["custom"]).To(ContainElement(jwt.String("custom", "127.0.0.1")))
+	})
+
+	It("should succeed, claim:custom(error type)", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.NamedError("custom", errors.New("failure")),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims["custom"]).To(ContainElement(jwt.String("custom", "failure")))
+	})
+
+	It("should fail to sign a nil error", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.NamedError("custom", nil),
+		)
+		Expect(err).To(HaveOccurred())
+		Expect(token).To(BeEmpty())
+	})
+
+	It("should fail to sign a typed nil stringer", func() {
+		claim := jwt.Any("custom", (*nilStringer)(nil))
+		Expect(claim.Type).To(Equal(jwt.StringerType))
+
+		_, err := claim.AsString()
+		Expect(err).To(MatchError(jwt.ErrInvalidClaimType))
+
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), claim)
+		Expect(err).To(HaveOccurred())
+		Expect(token).To(BeEmpty())
+	})
+
+	It("should fail to read the wrong claim type", func() {
+		_, err := jwt.Bool("custom", true).AsDuration()
+		Expect(err).To(MatchError(jwt.ErrInvalidClaimType))
+
+		_, err = jwt.String("custom", "not base64!").AsBinary()
+		Expect(err).To(MatchError(jwt.ErrInvalidClaimType))
+	})
+
+	It("should return typed values from claims", func() {
+		Expect(jwt.Time("custom", time.Unix(1600000000, 0)).AsTime()).To(BeTemporally("==", time.Unix(1600000000, 0)))
+		Expect(jwt.String("custom", "foobar").AsString()).To(Equal("foobar"))
+		Expect(jwt.Stringer("custom", net.IPv4(127, 0, 0, 1)).AsString()).To(Equal("127.0.0.1"))
+		Expect(jwt.Int("custom", 42).AsInt64()).To(Equal(int64(42)))
+		Expect(jwt.Float("custom", 4.2).AsFloat64()).To(Equal(4.2))
+		Expect(jwt.Bool("custom", true).AsBool()).To(BeTrue())
+		Expect(jwt.String("custom", "foobar").AsStrings()).To(Equal([]string{"foobar"}))
+		Expect(jwt.Reflect("custom", []interface{}{"foo", "bar"}).AsStrings()).To(Equal([]string{"foo", "bar"}))
+		Expect(jwt.Reflect("custom", map[string]interface{}{"foo": "bar"}).AsMap()).
+			To(Equal(map[string]interface{}{"foo": "bar"}))
+	})
+
+	DescribeTable("should fail to return mismatched typed values",
+		func(f func() error) {
+			Expect(f()).To(MatchError(jwt.ErrInvalidClaimType))
+		},
+		Entry("string", func() error { _, err := jwt.Bool("custom", true).AsString(); return err }),
+		Entry("fractional int", func() error { _, err := jwt.Float("custom", 4.2).AsInt64(); return err }),
+		Entry("float", func() error { _, err := jwt.String("custom", "4.2").AsFloat64(); return err }),
+		Entry("bool", func() error { _, err := jwt.String("custom", "true").AsBool(); return err }),
+		Entry("mixed array", func() error { _, err := jwt.Reflect("custom", []interface{}{"foo", 1.0}).AsStrings(); return err }),
+		Entry("map", func() error { _, err := jwt.Reflect("custom", []interface{}{}).AsMap(); return err }),
+	)
+})
@@ -0,0 +1,65 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to signers and verifiers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// SystemClock implements the `Clock` interface and returns the wall clock time.
+type SystemClock struct{}
+
+// Now returns the current wall clock time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock implements the `Clock` interface and returns a time that only changes when it is
+// set or advanced, it is intended for tests.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a `FakeClock` initialized to the supplied time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set changes the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Advance moves the current time of the clock forward by the supplied duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// clockOrSystem returns the supplied clock, or a `SystemClock` if it is nil.
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock{}
+	}
+
+	return c
+}
@@ -0,0 +1,90 @@
+package jwt_test
+
+import (
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Clock", func() {
+	var clock *jwt.FakeClock
+	var signer *jwt.RSASigner
+	var verifier *jwt.RSAVerifier
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+		privateKey, err := jwt.ParsePKCS1PrivateKeyFromFileAFS(createAfs(), "key.pem")
+		Expect(err).NotTo(HaveOccurred())
+
+		signer = &jwt.RSASigner{
+			Algorithm:  jwt.RS256,
+			PrivateKey: privateKey,
+			Clock:      clock,
+		}
+
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+	})
+
+	It("should set and advance the fake clock", func() {
+		now := clock.Now()
+
+		clock.Advance(time.Hour)
+		Expect(clock.Now()).To(Equal(now.Add(time.Hour)))
+
+		clock.Set(now)
+		Expect(clock.Now()).To(Equal(now))
+	})
+
+	It("should stamp the issued time from the signer clock", func() {
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.GetTime(jwt.Issued)).To(BeTemporally("==", clock.Now()))
+	})
+
+	It("should not override a supplied issued time", func() {
+		issued := clock.Now().Add(-1 * time.Minute)
+
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time(jwt.Issued, issued),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.GetTime(jwt.Issued)).To(BeTemporally("==", issued))
+	})
+
+	It("should verify against the verifier clock", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+
+		clock.Advance(time.Hour)
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenTimeNotValid))
+	})
+
+	It("should verify as of a supplied time", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.VerifyAt(token, clock.Now().Add(30*time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+
+		_, err = verifier.VerifyAt(token, clock.Now().Add(-1*time.Minute))
+		Expect(err).To(MatchError(jwt.ErrTokenTimeNotValid))
+	})
+})
@@ -0,0 +1,42 @@
+package jwt
+
+import (
+	"context"
+)
+
+// ContextVerifier is a `Verifier` that also accepts a context, the context is passed through to
+// every pluggable hook called during verification (eg. `ContextRule`s).
+type ContextVerifier interface {
+	Verifier
+	// VerifyContext processes a supplied token
+	VerifyContext(ctx context.Context, token []byte) (VerifyResult, error)
+}
+
+// NewContextVerifier returns the supplied verifier as a `ContextVerifier`. Verifiers that do not
+// implement `ContextVerifier` are wrapped, the wrapper returns the context error if it is done
+// before verification starts and otherwise calls Verify.
+func NewContextVerifier(verifier Verifier) ContextVerifier {
+	if cv, ok := verifier.(ContextVerifier); ok {
+		return cv
+	}
+
+	return contextVerifier{verifier}
+}
+
+// VerifyContext verifies a token with the supplied verifier and context, see `NewContextVerifier`.
+func VerifyContext(ctx context.Context, verifier Verifier, token []byte) (VerifyResult, error) {
+	return NewContextVerifier(verifier).VerifyContext(ctx, token)
+}
+
+// contextVerifier adapts a `Verifier` to the `ContextVerifier` interface.
+type contextVerifier struct {
+	Verifier
+}
+
+func (v contextVerifier) VerifyContext(ctx context.Context, token []byte) (VerifyResult, error) {
+	if err := ctx.Err(); err != nil {
+		return VerifyResult{}, err
+	}
+
+	return v.Verify(token)
+}
@@ -0,0 +1,83 @@
+package jwt_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type contextKey struct{}
+
+type staticVerifier struct {
+	result jwt.VerifyResult
+}
+
+func (v staticVerifier) Verify(token []byte) (jwt.VerifyResult, error) {
+	return v.result, nil
+}
+
+var _ = Describe("JWT Context Verifier", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var token []byte
+
+	BeforeEach(func() {
+		var err error
+
+		signer = createSigner()
+		verifier = createRSAVerifier()
+
+		token, err = jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should pass the context through to context rules", func() {
+		var seen interface{}
+
+		verifier.Rules = []jwt.Rule{
+			jwt.AllOf(
+				jwt.ContextRuleFunc(func(ctx context.Context, result jwt.VerifyResult) error {
+					seen = ctx.Value(contextKey{})
+
+					return nil
+				}),
+			),
+		}
+
+		ctx := context.WithValue(context.Background(), contextKey{}, "value")
+
+		result, err := jwt.VerifyContext(ctx, verifier, token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+		Expect(seen).To(Equal("value"))
+	})
+
+	It("should return the context error when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("should return a context verifier unchanged", func() {
+		Expect(jwt.NewContextVerifier(verifier)).To(BeIdenticalTo(verifier))
+	})
+
+	It("should adapt a verifier without context support", func() {
+		cv := jwt.NewContextVerifier(staticVerifier{jwt.VerifyResult{Subject: "static"}})
+
+		result, err := cv.VerifyContext(context.Background(), token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("static"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = cv.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,451 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pascaldekloe/jwt"
+)
+
+// DPoPHeader is the HTTP header carrying a DPoP proof (RFC 9449).
+const DPoPHeader = "DPoP"
+
+// DPoPProofType is the typ header of a DPoP proof.
+const DPoPProofType = "dpop+jwt"
+
+// KeyThumbprintMember is the `Confirmation` member holding the JWK thumbprint of the DPoP key a
+// token is bound to (RFC 9449).
+const KeyThumbprintMember string = "jkt"
+
+// DefaultDPoPMaxAge is the default maximum age of a DPoP proof.
+const DefaultDPoPMaxAge = time.Minute
+
+// ErrDPoPProofInvalid is the error returned when a DPoP proof is malformed, has an invalid signature
+// or does not match the request.
+var ErrDPoPProofInvalid = errors.New("invalid DPoP proof")
+
+// ErrDPoPProofReplayed is the error returned when a DPoP proof has already been used.
+var ErrDPoPProofReplayed = errors.New("DPoP proof has already been used")
+
+// ErrTokenDPoPMismatch is the error returned when a DPoP-bound token is not presented with a proof
+// signed by the key it was issued to.
+var ErrTokenDPoPMismatch = errors.New("token is not bound to the DPoP key")
+
+// DPoPProof is a validated DPoP proof.
+type DPoPProof struct {
+	// ID is the unique identifier of the proof (the jti claim).
+	ID string
+	// Method is the HTTP method of the request the proof was created for (the htm claim).
+	Method string
+	// URI is the HTTP URI of the request the proof was created for (the htu claim).
+	URI string
+	// Issued is the time the proof was created (the iat claim).
+	Issued time.Time
+	// Thumbprint is the JWK thumbprint of the key that signed the proof, see `KeyThumbprintMember`.
+	Thumbprint string
+}
+
+// DPoPSigner creates DPoP proofs signed with an ECDSA key, the public key is embedded in every proof.
+type DPoPSigner struct {
+	PrivateKey *ecdsa.PrivateKey
+	// Clock provides the iat claim of proofs, it defaults to the wall clock.
+	Clock Clock
+}
+
+// Thumbprint returns the JWK thumbprint of the signer's public key, it is used to bind tokens
+// to the signer (see `SignDPoPBound`).
+func (s *DPoPSigner) Thumbprint() (string, error) {
+	return KeyThumbprint(&s.PrivateKey.PublicKey)
+}
+
+// Proof returns a DPoP proof for a request with the supplied method and URI. The access token,
+// when supplied, is bound to the proof by its hash (the ath claim).
+func (s *DPoPSigner) Proof(method, uri string, accessToken []byte) ([]byte, error) {
+	alg, err := ecdsaAlgorithm(s.PrivateKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := marshalJWK(&s.PrivateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json.Marshal(map[string]interface{}{
+		"typ": DPoPProofType,
+		"jwk": json.RawMessage(jwk),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []Claim{
+		String("htm", method),
+		String("htu", uri),
+		Time(Issued, time.Unix(clockOrSystem(s.Clock).Now().Unix(), 0)),
+	}
+
+	if len(accessToken) > 0 {
+		claims = append(claims, String("ath", accessTokenHash(accessToken)))
+	}
+
+	tokenClaims, err := ConstructClaimsFromSlice(claims...)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenClaims.ECDSASign(alg, s.PrivateKey, header)
+}
+
+// DPoPConfirmation returns a cnf claim binding a token to the DPoP key with the supplied thumbprint.
+func DPoPConfirmation(thumbprint string) Claim {
+	return Reflect(Confirmation, map[string]interface{}{
+		KeyThumbprintMember: thumbprint,
+	})
+}
+
+// SignDPoPBound takes a signer, a DPoP key thumbprint and a list of claims and produces a signed
+// token that is only valid when presented with a DPoP proof signed by the key.
+func SignDPoPBound(signer Signer, thumbprint string, claims ...Claim) ([]byte, error) {
+	return signer.SignClaims(append(claims, DPoPConfirmation(thumbprint))...)
+}
+
+// DPoPVerifier validates DPoP proofs.
+type DPoPVerifier struct {
+	// MaxAge is the maximum age of a proof, it defaults to `DefaultDPoPMaxAge`.
+	MaxAge time.Duration
+	// Leeway is the tolerance for clock skew between the client and the verifier.
+	Leeway time.Duration
+	// Clock provides the time proofs are verified at, it defaults to the wall clock.
+	Clock Clock
+	// Replay, when set, records the proof IDs so that each proof can only be used once.
+	Replay ReplayStore
+}
+
+// VerifyProof validates the DPoP proof for a request with the supplied method and URI. The access
+// token, when supplied, must match the hash in the proof (the ath claim).
+func (v *DPoPVerifier) VerifyProof(
+	ctx context.Context,
+	proof []byte,
+	method,
+	uri string,
+	accessToken []byte,
+) (DPoPProof, error) {
+	claims, thumbprint, err := checkDPoPProof(proof)
+	if err != nil {
+		return DPoPProof{}, err
+	}
+
+	result := DPoPProof{
+		ID:         claims.ID,
+		Thumbprint: thumbprint,
+	}
+
+	result.Method, _ = claims.String("htm")
+	result.URI, _ = claims.String("htu")
+
+	if claims.ID == "" || claims.Issued == nil {
+		return DPoPProof{}, fmt.Errorf("%w: jti and iat are required", ErrDPoPProofInvalid)
+	}
+
+	result.Issued = claims.Issued.Time()
+
+	if result.Method != method {
+		return DPoPProof{}, fmt.Errorf("%w: htm does not match the request method", ErrDPoPProofInvalid)
+	}
+
+	if !matchHTU(result.URI, uri) {
+		return DPoPProof{}, fmt.Errorf("%w: htu does not match the request URI", ErrDPoPProofInvalid)
+	}
+
+	now := clockOrSystem(v.Clock).Now()
+
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultDPoPMaxAge
+	}
+
+	if result.Issued.After(now.Add(v.Leeway)) || now.Sub(result.Issued) > maxAge+v.Leeway {
+		return DPoPProof{}, fmt.Errorf("%w: iat is outside the acceptable window", ErrDPoPProofInvalid)
+	}
+
+	if len(accessToken) > 0 {
+		ath, _ := claims.String("ath")
+		if subtle.ConstantTimeCompare([]byte(ath), []byte(accessTokenHash(accessToken))) != 1 {
+			return DPoPProof{}, fmt.Errorf("%w: ath does not match the access token", ErrDPoPProofInvalid)
+		}
+	}
+
+	if v.Replay != nil {
+		first, err := v.Replay.Use(ctx, claims.ID, result.Issued.Add(maxAge+v.Leeway))
+		if err != nil {
+			return DPoPProof{}, fmt.Errorf("replay lookup failed: %w", err)
+		}
+
+		if !first {
+			return DPoPProof{}, ErrDPoPProofReplayed
+		}
+	}
+
+	return result, nil
+}
+
+// VerifyDPoPBinding returns `ErrTokenDPoPMismatch` if the cnf claim of the verified token does not
+// match the thumbprint of the key that signed the DPoP proof.
+func VerifyDPoPBinding(result VerifyResult, proof DPoPProof) error {
+	cnf, err := result.GetMap(Confirmation)
+	if err != nil {
+		return ErrTokenDPoPMismatch
+	}
+
+	want, ok := cnf[KeyThumbprintMember].(string)
+	if !ok || subtle.ConstantTimeCompare([]byte(proof.Thumbprint), []byte(want)) != 1 {
+		return ErrTokenDPoPMismatch
+	}
+
+	return nil
+}
+
+// dpopRequest is the DPoP proof and request held in a context.
+type dpopRequest struct {
+	proof  []byte
+	method string
+	uri    string
+}
+
+// dpopRequestKey is the context key for the DPoP proof and request.
+type dpopRequestKey struct{}
+
+// ContextWithDPoPProof returns a copy of the context holding the DPoP proof and the method and URI
+// of the request it was presented with, it is used by verifiers to check DPoP-bound tokens.
+func ContextWithDPoPProof(ctx context.Context, proof []byte, method, uri string) context.Context {
+	return context.WithValue(ctx, dpopRequestKey{}, dpopRequest{proof: proof, method: method, uri: uri})
+}
+
+// ContextWithDPoPRequest is the same as ContextWithDPoPProof, but takes the proof, method and URI
+// from the `DPoPHeader` of an HTTP request.
+func ContextWithDPoPRequest(ctx context.Context, r *http.Request) context.Context {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	uri := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path}
+
+	return ContextWithDPoPProof(ctx, []byte(r.Header.Get(DPoPHeader)), r.Method, uri.String())
+}
+
+// dpopRequestFromContext returns the DPoP proof and request held by the context, if any.
+func dpopRequestFromContext(ctx context.Context) (dpopRequest, bool) {
+	req, ok := ctx.Value(dpopRequestKey{}).(dpopRequest)
+
+	return req, ok && len(req.proof) > 0
+}
+
+// checkDPoPProof checks the signature of a DPoP proof against the key in its header and returns the
+// claims and the key thumbprint.
+func checkDPoPProof(proof []byte) (*jwt.Claims, string, error) {
+	parsed, err := jwt.ParseWithoutCheck(proof)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrDPoPProofInvalid, err)
+	}
+
+	var header struct {
+		Typ string          `json:"typ"`
+		JWK json.RawMessage `json:"jwk"`
+	}
+
+	if err := json.Unmarshal(parsed.RawHeader, &header); err != nil || header.Typ != DPoPProofType {
+		return nil, "", fmt.Errorf("%w: typ must be %s", ErrDPoPProofInvalid, DPoPProofType)
+	}
+
+	key, err := parseJWK(header.JWK)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrDPoPProofInvalid, err)
+	}
+
+	var claims *jwt.Claims
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		claims, err = jwt.ECDSACheck(proof, k)
+	case *rsa.PublicKey:
+		claims, err = jwt.RSACheck(proof, k)
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrDPoPProofInvalid, err)
+	}
+
+	thumbprint, err := KeyThumbprint(key)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrDPoPProofInvalid, err)
+	}
+
+	return claims, thumbprint, nil
+}
+
+// jsonWebKey is the public part of an EC or RSA JSON Web Key (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+}
+
+// KeyThumbprint returns the RFC 7638 JWK thumbprint of an ECDSA or RSA public key.
+func KeyThumbprint(key interface{}) (string, error) {
+	jwk, err := newJSONWebKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	// the required members in lexicographic order, see RFC 7638 section 3.2.
+	var members string
+	if jwk.Kty == "EC" {
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, jwk.Crv, jwk.Kty, jwk.X, jwk.Y)
+	} else {
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, jwk.E, jwk.Kty, jwk.N)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// newJSONWebKey returns the JSON Web Key for an ECDSA or RSA public key.
+func newJSONWebKey(key interface{}) (jsonWebKey, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+
+		return jsonWebKey{
+			Kty: "EC",
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *rsa.PublicKey:
+		return jsonWebKey{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	default:
+		return jsonWebKey{}, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// marshalJWK returns the JSON encoded JSON Web Key for an ECDSA or RSA public key.
+func marshalJWK(key interface{}) ([]byte, error) {
+	jwk, err := newJSONWebKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jwk)
+}
+
+// parseJWK returns the ECDSA or RSA public key of a JSON encoded JSON Web Key, keys with private
+// members are rejected.
+func parseJWK(data []byte) (interface{}, error) {
+	var jwk jsonWebKey
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, errors.New("jwk header is required")
+	}
+
+	if jwk.D != "" {
+		return nil, errors.New("jwk must not contain a private key")
+	}
+
+	switch jwk.Kty {
+	case "EC":
+		var curve elliptic.Curve
+
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+
+		if errX != nil || errY != nil {
+			return nil, errors.New("malformed EC jwk")
+		}
+
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC jwk is not on the curve")
+		}
+
+		return key, nil
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("malformed RSA jwk")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+// ecdsaAlgorithm returns the signing algorithm for an ECDSA curve.
+func ecdsaAlgorithm(curve elliptic.Curve) (string, error) {
+	switch curve.Params().Name {
+	case "P-256":
+		return jwt.ES256, nil
+	case "P-384":
+		return jwt.ES384, nil
+	case "P-521":
+		return jwt.ES512, nil
+	default:
+		return "", fmt.Errorf("unsupported curve %q", curve.Params().Name)
+	}
+}
+
+// accessTokenHash returns the base64url encoded SHA-256 hash of an access token (the ath claim).
+func accessTokenHash(token []byte) string {
+	sum := sha256.Sum256(token)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// matchHTU returns true if the htu claim matches the request URI, ignoring the query and fragment
+// and the case of the scheme and host (RFC 9449 section 4.3).
+func matchHTU(htu, uri string) bool {
+	a, errA := url.Parse(htu)
+	b, errB := url.Parse(uri)
+
+	if errA != nil || errB != nil || a.Host == "" {
+		return false
+	}
+
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) && a.EscapedPath() == b.EscapedPath()
+}
@@ -0,0 +1,248 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func createDPoPSigner(clock jwt.Clock) *jwt.DPoPSigner {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &jwt.DPoPSigner{
+		PrivateKey: privateKey,
+		Clock:      clock,
+	}
+}
+
+var _ = Describe("JWT DPoP", func() {
+	const uri = "https://api.example.com/orders"
+
+	var clock *jwt.FakeClock
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var dpop *jwt.DPoPSigner
+	var token []byte
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		signer = createSigner()
+		dpop = createDPoPSigner(clock)
+
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+		verifier.DPoP = &jwt.DPoPVerifier{
+			Clock:  clock,
+			Replay: jwt.NewMemoryReplayStore(),
+		}
+
+		thumbprint, err := dpop.Thumbprint()
+		Expect(err).NotTo(HaveOccurred())
+
+		token, err = jwt.SignDPoPBound(
+			signer,
+			thumbprint,
+			jwt.String(jwt.Subject, "subject"),
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time(jwt.Expires, clock.Now().Add(time.Hour)),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should accept a token with a valid proof", func() {
+		proof, err := dpop.Proof(http.MethodGet, uri, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, uri+"?page=2")
+
+		result, err := verifier.VerifyContext(ctx, token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+	})
+
+	It("should validate a proof independently of a token", func() {
+		proof, err := dpop.Proof(http.MethodPost, uri, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.DPoP.VerifyProof(context.Background(), proof, http.MethodPost, uri, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Method).To(Equal(http.MethodPost))
+		Expect(result.URI).To(Equal(uri))
+		Expect(result.ID).NotTo(BeEmpty())
+		Expect(result.Issued).To(BeTemporally("==", clock.Now()))
+
+		thumbprint, err := dpop.Thumbprint()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Thumbprint).To(Equal(thumbprint))
+	})
+
+	It("should take the proof from an HTTP request", func() {
+		proof, err := dpop.Proof(http.MethodGet, "http://api.example.com/orders", token)
+		Expect(err).NotTo(HaveOccurred())
+
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/orders?page=2", nil)
+		req.Header.Set(jwt.DPoPHeader, string(proof))
+
+		_, err = verifier.VerifyContext(jwt.ContextWithDPoPRequest(context.Background(), req), token)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a replayed proof", func() {
+		proof, err := dpop.Proof(http.MethodGet, uri, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, uri)
+
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofReplayed))
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorReplayed))
+	})
+
+	It("should reject a missing proof", func() {
+		_, err := verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorBinding))
+	})
+
+	It("should reject a proof for another request", func() {
+		proof, err := dpop.Proof(http.MethodGet, uri, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodPost, uri)
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+
+		ctx = jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, "https://api.example.com/users")
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+	})
+
+	It("should reject a proof for another access token", func() {
+		other, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		proof, err := dpop.Proof(http.MethodGet, uri, other)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, uri)
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+	})
+
+	It("should reject an expired proof", func() {
+		proof, err := dpop.Proof(http.MethodGet, uri, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		clock.Advance(2 * jwt.DefaultDPoPMaxAge)
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, uri)
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+	})
+
+	It("should reject a proof signed by another key", func() {
+		proof, err := createDPoPSigner(clock).Proof(http.MethodGet, uri, token)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithDPoPProof(context.Background(), proof, http.MethodGet, uri)
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrTokenDPoPMismatch))
+	})
+
+	It("should reject a token that is not a proof", func() {
+		ctx := jwt.ContextWithDPoPProof(context.Background(), token, http.MethodGet, uri)
+		_, err := verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrDPoPProofInvalid))
+	})
+
+	Context("with middleware", func() {
+		var handler http.Handler
+
+		serve := func(scheme string, proof []byte) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, uri, nil)
+			req.Header.Set("Authorization", scheme+" "+string(token))
+
+			if proof != nil {
+				req.Header.Set(jwt.DPoPHeader, string(proof))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			return rec
+		}
+
+		BeforeEach(func() {
+			middleware := &jwt.Middleware{Verifier: verifier, Realm: "api"}
+			handler = middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		})
+
+		It("should accept the DPoP authorization scheme with a proof", func() {
+			proof, err := dpop.Proof(http.MethodGet, uri, token)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(serve("DPoP", proof).Code).To(Equal(http.StatusOK))
+		})
+
+		It("should not accept the DPoP authorization scheme without a proof", func() {
+			rec := serve("DPoP", nil)
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="api"`))
+		})
+
+		It("should challenge with the DPoP scheme when the proof is invalid", func() {
+			proof, err := dpop.Proof(http.MethodPost, uri, token)
+			Expect(err).NotTo(HaveOccurred())
+
+			rec := serve("DPoP", proof)
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(Equal(
+				`DPoP realm="api", error="invalid_dpop_proof", error_description="the DPoP proof is invalid", ` +
+					`algs="ES256 ES384 ES512"`,
+			))
+		})
+
+		It("should challenge with the DPoP scheme when the token is not bound to the proof key", func() {
+			proof, err := createDPoPSigner(clock).Proof(http.MethodGet, uri, token)
+			Expect(err).NotTo(HaveOccurred())
+
+			rec := serve("DPoP", proof)
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix(`DPoP realm="api", error="invalid_token"`))
+		})
+	})
+
+	It("should compute the RFC 7638 key thumbprint", func() {
+		n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAt" +
+			"VT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0" +
+			"h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-b" +
+			"FTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+		Expect(err).NotTo(HaveOccurred())
+
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+
+		Expect(jwt.KeyThumbprint(key)).To(Equal("NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"))
+	})
+})
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pascaldekloe/jwt"
+)
+
+// timeNotValidError is a sentinel error that also matches `ErrTokenTimeNotValid`.
+type timeNotValidError struct {
+	msg string
+}
+
+func (e *timeNotValidError) Error() string {
+	return e.msg
+}
+
+func (e *timeNotValidError) Is(target error) bool {
+	return target == ErrTokenTimeNotValid
+}
+
+// TokenTimeError is the error returned when a token is outside its NotBefore or Expires times,
+// it matches `ErrTokenTimeNotValid` and either `ErrTokenExpired` or `ErrTokenNotYetValid`.
+type TokenTimeError struct {
+	// Err is either `ErrTokenExpired` or `ErrTokenNotYetValid`.
+	Err error
+	// Time is the offending exp or nbf time of the token.
+	Time time.Time
+	// CheckTime is the time the token was verified at.
+	CheckTime time.Time
+	// Leeway is the clock skew tolerance of the verifier.
+	Leeway time.Duration
+}
+
+// Error returns the error message including the offending and verification times.
+func (e *TokenTimeError) Error() string {
+	field := Expires
+	if errors.Is(e.Err, ErrTokenNotYetValid) {
+		field = NotBefore
+	}
+
+	return fmt.Sprintf(
+		"%s (%s: %s, checked at: %s, leeway: %s)",
+		e.Err,
+		field,
+		e.Time.UTC().Format(time.RFC3339Nano),
+		e.CheckTime.UTC().Format(time.RFC3339Nano),
+		e.Leeway,
+	)
+}
+
+// Unwrap returns the underlying sentinel error.
+func (e *TokenTimeError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrorFlag identifies a failed check in a `ValidationError`, flags can be combined.
+type ValidationErrorFlag uint32
+
+const (
+	// ValidationErrorMalformed indicates that the token could not be parsed.
+	ValidationErrorMalformed ValidationErrorFlag = 1 << iota
+	// ValidationErrorSignatureInvalid indicates that the token signature or algorithm is not valid.
+	ValidationErrorSignatureInvalid
+	// ValidationErrorAudience indicates that the token audience did not match.
+	ValidationErrorAudience
+	// ValidationErrorExpired indicates that the token has expired.
+	ValidationErrorExpired
+	// ValidationErrorNotValidYet indicates that the token is not valid yet.
+	ValidationErrorNotValidYet
+	// ValidationErrorPolicy indicates that the token failed an expiry, issued or lifetime policy.
+	ValidationErrorPolicy
+	// ValidationErrorClaimType indicates that a claim did not match the type declared in the `ClaimSchema`.
+	ValidationErrorClaimType
+	// ValidationErrorClaim indicates that a claim failed a verification `Rule`.
+	ValidationErrorClaim
+	// ValidationErrorRevoked indicates that the token has been revoked.
+	ValidationErrorRevoked
+	// ValidationErrorReplayed indicates that a one-time token has already been used.
+	ValidationErrorReplayed
+	// ValidationErrorLookup indicates that a store or remote lookup failed during verification,
+	// so the token could not be verified.
+	ValidationErrorLookup
+	// ValidationErrorBinding indicates that the token is not bound to the client presenting it.
+	ValidationErrorBinding
+)
+
+// ValidationError is the error returned by verifiers, it collects every failed check.
+// `errors.Is` and `errors.As` match against any of the collected errors.
+type ValidationError struct {
+	// Flags is the combination of all failed checks.
+	Flags ValidationErrorFlag
+	// Errors contains the error for each failed check.
+	Errors []error
+	// Result contains the parsed token, it is only populated when the signature was valid
+	// and is intended for logging, it must not be trusted.
+	Result VerifyResult
+}
+
+// newParseError returns a `ValidationError` for an error returned while checking the token signature.
+func newParseError(err error) *ValidationError {
+	verr := &ValidationError{}
+
+	var algErr jwt.AlgError
+	if errors.Is(err, jwt.ErrSigMiss) || errors.As(err, &algErr) {
+		verr.add(ValidationErrorSignatureInvalid, err)
+	} else {
+		verr.add(ValidationErrorMalformed, err)
+	}
+
+	return verr
+}
+
+// add records a failed check, errors that have already been recorded only add their flag.
+func (e *ValidationError) add(flag ValidationErrorFlag, err error) {
+	e.Flags |= flag
+
+	for _, existing := range e.Errors {
+		if existing == err {
+			return
+		}
+	}
+
+	e.Errors = append(e.Errors, err)
+}
+
+// Has returns true if any of the supplied flags are set.
+func (e *ValidationError) Has(flags ValidationErrorFlag) bool {
+	return e.Flags&flags != 0
+}
+
+// Error returns the messages of all collected errors.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Is returns true if any of the collected errors match the target.
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first collected error that matches the target, and if so, sets the target to that error value.
+func (e *ValidationError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLookupError returns true if the token could not be verified only because a lookup failed
+// (see `ValidationErrorLookup`) or the context was cancelled, rather than because the token is invalid.
+func IsLookupError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var verr *ValidationError
+
+	return errors.As(err, &verr) && verr.Flags == ValidationErrorLookup
+}
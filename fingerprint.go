@@ -0,0 +1,89 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// FingerprintCookie is the name of the cookie holding the token fingerprint, the `__Secure-` prefix
+// makes browsers reject the cookie unless it is set over HTTPS with the Secure attribute.
+const FingerprintCookie = "__Secure-Fgp"
+
+// ErrTokenFingerprintInvalid is the error returned when the fingerprint supplied with a token
+// does not match the hash in its fpt claim.
+var ErrTokenFingerprintInvalid = errors.New("token fingerprint is not valid")
+
+// NewFingerprint returns a random fingerprint and its SHA-256 hash, the fingerprint is given to
+// the client (see `IssueFingerprint`) and the hash is stored in the token (see `SignFingerprint`).
+func NewFingerprint() (fingerprint, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	fingerprint = hex.EncodeToString(b)
+
+	return fingerprint, FingerprintHash(fingerprint), nil
+}
+
+// FingerprintHash returns the hex encoded SHA-256 hash of the fingerprint.
+func FingerprintHash(fingerprint string) string {
+	sum := sha256.Sum256([]byte(fingerprint))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NewFingerprintCookie returns a hardened cookie holding the fingerprint, it is HttpOnly, Secure
+// and SameSite=Strict so it is only sent with first-party HTTPS requests and hidden from scripts.
+func NewFingerprintCookie(fingerprint string) *http.Cookie {
+	return &http.Cookie{
+		Name:     FingerprintCookie,
+		Value:    fingerprint,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// IssueFingerprint generates a new fingerprint, sets it as a cookie on the response and returns
+// the hash to pass to `SignFingerprint`.
+func IssueFingerprint(w http.ResponseWriter) (string, error) {
+	fingerprint, hash, err := NewFingerprint()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, NewFingerprintCookie(fingerprint))
+
+	return hash, nil
+}
+
+// VerifyFingerprint returns `ErrTokenFingerprintInvalid` if the hash of the fingerprint does not
+// match the fpt claim of the verified token.
+func VerifyFingerprint(result VerifyResult, fingerprint string) error {
+	if result.Fingerprint == "" || fingerprint == "" {
+		return ErrTokenFingerprintInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(FingerprintHash(fingerprint)), []byte(result.Fingerprint)) != 1 {
+		return ErrTokenFingerprintInvalid
+	}
+
+	return nil
+}
+
+// VerifyFingerprintCookie is the same as VerifyFingerprint, but takes the fingerprint from the
+// request's `FingerprintCookie`.
+func VerifyFingerprintCookie(result VerifyResult, r *http.Request) error {
+	cookie, err := r.Cookie(FingerprintCookie)
+	if err != nil {
+		return ErrTokenFingerprintInvalid
+	}
+
+	return VerifyFingerprint(result, cookie.Value)
+}
@@ -0,0 +1,77 @@
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Fingerprint", func() {
+	var signer jwt.Signer
+	var verifier jwt.Verifier
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createVerifier()
+	})
+
+	It("should generate a random fingerprint and its hash", func() {
+		fingerprint, hash, err := jwt.NewFingerprint()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fingerprint).To(HaveLen(64))
+		Expect(hash).To(Equal(jwt.FingerprintHash(fingerprint)))
+		Expect(hash).NotTo(Equal(fingerprint))
+
+		other, _, err := jwt.NewFingerprint()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(other).NotTo(Equal(fingerprint))
+	})
+
+	It("should set a hardened cookie and verify it against the token", func() {
+		rec := httptest.NewRecorder()
+
+		hash, err := jwt.IssueFingerprint(rec)
+		Expect(err).NotTo(HaveOccurred())
+
+		cookies := rec.Result().Cookies()
+		Expect(cookies).To(HaveLen(1))
+		Expect(cookies[0].Name).To(Equal(jwt.FingerprintCookie))
+		Expect(cookies[0].HttpOnly).To(BeTrue())
+		Expect(cookies[0].Secure).To(BeTrue())
+		Expect(cookies[0].SameSite).To(Equal(http.SameSiteStrictMode))
+		Expect(jwt.FingerprintHash(cookies[0].Value)).To(Equal(hash))
+
+		token, err := jwt.SignFingerprint(signer, "subject", "audience", hash, false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Fingerprint).To(Equal(hash))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		Expect(jwt.VerifyFingerprintCookie(result, req)).To(MatchError(jwt.ErrTokenFingerprintInvalid))
+
+		req.AddCookie(&http.Cookie{Name: jwt.FingerprintCookie, Value: "stolen"})
+		Expect(jwt.VerifyFingerprintCookie(result, req)).To(MatchError(jwt.ErrTokenFingerprintInvalid))
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		Expect(jwt.VerifyFingerprintCookie(result, req)).To(Succeed())
+	})
+
+	It("should reject tokens without a fingerprint", func() {
+		fingerprint, _, err := jwt.NewFingerprint()
+		Expect(err).NotTo(HaveOccurred())
+
+		token, err := jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jwt.VerifyFingerprint(result, fingerprint)).To(MatchError(jwt.ErrTokenFingerprintInvalid))
+	})
+})
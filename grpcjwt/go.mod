@@ -0,0 +1,31 @@
+module github.com/koshatul/jwt/v2/grpcjwt
+
+go 1.17
+
+require (
+	github.com/koshatul/jwt/v2 v2.0.0-00010101000000-000000000000
+	github.com/onsi/ginkgo v1.12.0
+	github.com/onsi/gomega v1.9.0
+	google.golang.org/grpc v1.43.0
+)
+
+require (
+	github.com/golang/protobuf v1.4.3 // indirect
+	github.com/hpcloud/tail v1.0.0 // indirect
+	github.com/pascaldekloe/jwt v1.10.0 // indirect
+	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b // indirect
+	github.com/spf13/afero v1.6.0 // indirect
+	golang.org/x/net v0.0.0-20200822124328-c89045814202 // indirect
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/protobuf v1.25.0 // indirect
+	gopkg.in/fsnotify.v1 v1.4.7 // indirect
+	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// The jwt module is developed alongside this one, see the package documentation for the release
+// order before removing this directive.
+replace github.com/koshatul/jwt/v2 => ../
@@ -0,0 +1,166 @@
+// Package grpcjwt provides gRPC server interceptors and client credentials for tokens
+// verified and signed by the jwt package. It is a separate module so that users of the jwt
+// package do not depend on gRPC.
+//
+// Within this repository the module uses the jwt module in the parent directory through a
+// replace directive. To release it, tag the jwt module first, then require that version in
+// place of the placeholder, drop the replace directive and tag this module (`grpcjwt/vX.Y.Z`).
+package grpcjwt
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/koshatul/jwt/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationKey is the metadata key carrying the bearer token.
+const AuthorizationKey = "authorization"
+
+// UnaryServerInterceptor returns a `grpc.UnaryServerInterceptor` that verifies the bearer token of
+// each call and stores the result in the context (see `jwt.ResultFromContext`).
+func UnaryServerInterceptor(verifier jwt.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, verifier)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a `grpc.StreamServerInterceptor` that verifies the bearer token of
+// each stream and stores the result in the stream context (see `jwt.ResultFromContext`).
+func StreamServerInterceptor(verifier jwt.Verifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), verifier)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream is a `grpc.ServerStream` with a replaced context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate verifies the bearer token in the incoming metadata and returns a context holding the result.
+// The TLS connection state of the peer is passed to the verifier for certificate-bound tokens.
+func authenticate(ctx context.Context, verifier jwt.Verifier) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, Status(err).Err()
+	}
+
+	vctx := ctx
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			vctx = jwt.ContextWithConnectionState(ctx, &info.State)
+		}
+	}
+
+	result, err := jwt.VerifyContext(vctx, verifier, token)
+	if err != nil {
+		return nil, Status(err).Err()
+	}
+
+	return jwt.ContextWithResult(ctx, result), nil
+}
+
+// bearerToken returns the bearer token from the incoming metadata.
+func bearerToken(ctx context.Context) ([]byte, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(AuthorizationKey)
+	if len(values) == 0 {
+		return nil, jwt.ErrTokenNotFound
+	}
+
+	if len(values) > 1 {
+		return nil, jwt.ErrTokenRequestInvalid
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+		return nil, jwt.ErrTokenRequestInvalid
+	}
+
+	return []byte(strings.TrimSpace(parts[1])), nil
+}
+
+// Status returns the gRPC status for an authentication error. Tokens that are authentic but fail
+// a claim rule or lack a required scope are `codes.PermissionDenied`, tokens that could not be
+// verified because a lookup failed (see `jwt.IsLookupError`) are `codes.Unavailable`, all other
+// errors are `codes.Unauthenticated`.
+func Status(err error) *status.Status {
+	if jwt.IsLookupError(err) {
+		return status.New(codes.Unavailable, "the access token could not be verified")
+	}
+
+	var verr *jwt.ValidationError
+
+	if errors.As(err, &verr) && verr.Flags == jwt.ValidationErrorClaim {
+		return status.New(codes.PermissionDenied, "permission denied")
+	}
+
+	if errors.Is(err, jwt.ErrInsufficientScope) {
+		return status.New(codes.PermissionDenied, "insufficient scope")
+	}
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenNotFound):
+		return status.New(codes.Unauthenticated, "missing bearer token")
+	case errors.Is(err, jwt.ErrTokenRequestInvalid):
+		return status.New(codes.Unauthenticated, "malformed bearer token")
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return status.New(codes.Unauthenticated, "the access token expired")
+	default:
+		return status.New(codes.Unauthenticated, "the access token is invalid")
+	}
+}
+
+// Credentials implements the `credentials.PerRPCCredentials` interface and attaches a token signed
+// by the embedded `jwt.SignerTokenSource` to every call.
+type Credentials struct {
+	jwt.SignerTokenSource
+	// Audience is the audience of the tokens, it defaults to the URI of the called service.
+	Audience string
+	// AllowInsecure allows the credentials to be sent over connections without transport security.
+	AllowInsecure bool
+}
+
+// GetRequestMetadata returns the authorization metadata with a newly signed token.
+func (c *Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	audience := c.Audience
+	if audience == "" && len(uri) > 0 {
+		audience = uri[0]
+	}
+
+	token, _, err := c.Token(ctx, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{AuthorizationKey: "Bearer " + string(token)}, nil
+}
+
+// RequireTransportSecurity returns true unless `AllowInsecure` is set.
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
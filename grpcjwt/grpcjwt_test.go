@@ -0,0 +1,205 @@
+package grpcjwt_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	"github.com/koshatul/jwt/v2/grpcjwt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthServer records the subject of the verified result in the context of each call.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	subjects chan string
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	subject, _ := jwt.SubjectFromContext(ctx)
+	s.subjects <- subject
+
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	subject, _ := jwt.SubjectFromContext(stream.Context())
+	s.subjects <- subject
+
+	return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+// failingRevocationStore is a revocation store that is always unavailable.
+type failingRevocationStore struct{}
+
+func (failingRevocationStore) Revoke(ctx context.Context, id string, expires time.Time) error {
+	return errors.New("store unavailable")
+}
+
+func (failingRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+var _ = Describe("gRPC Interceptors", func() {
+	var signer *jwt.RSASigner
+	var verifier *jwt.RSAVerifier
+	var server *grpc.Server
+	var service *healthServer
+	var listener *bufconn.Listener
+	var conn *grpc.ClientConn
+
+	dial := func(opts ...grpc.DialOption) grpc_health_v1.HealthClient {
+		opts = append(
+			opts,
+			grpc.WithInsecure(),
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+		)
+
+		var err error
+		conn, err = grpc.Dial("bufnet", opts...)
+		Expect(err).NotTo(HaveOccurred())
+
+		return grpc_health_v1.NewHealthClient(conn)
+	}
+
+	BeforeEach(func() {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		signer = &jwt.RSASigner{PrivateKey: privateKey, Algorithm: jwt.RS256}
+		verifier = &jwt.RSAVerifier{PublicKey: &privateKey.PublicKey, Audience: "health"}
+
+		service = &healthServer{subjects: make(chan string, 1)}
+		listener = bufconn.Listen(1024 * 1024)
+		server = grpc.NewServer(
+			grpc.UnaryInterceptor(grpcjwt.UnaryServerInterceptor(verifier)),
+			grpc.StreamInterceptor(grpcjwt.StreamServerInterceptor(verifier)),
+		)
+		grpc_health_v1.RegisterHealthServer(server, service)
+
+		go func() {
+			_ = server.Serve(listener)
+		}()
+	})
+
+	AfterEach(func() {
+		if conn != nil {
+			_ = conn.Close()
+			conn = nil
+		}
+
+		server.Stop()
+	})
+
+	It("should authenticate unary calls with the credentials", func() {
+		client := dial(grpc.WithPerRPCCredentials(&grpcjwt.Credentials{
+			SignerTokenSource: jwt.SignerTokenSource{
+				Signer:  signer,
+				Subject: "client",
+			},
+			Audience:      "health",
+			AllowInsecure: true,
+		}))
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.subjects).To(Receive(Equal("client")))
+	})
+
+	It("should authenticate streams with the credentials", func() {
+		client := dial(grpc.WithPerRPCCredentials(&grpcjwt.Credentials{
+			SignerTokenSource: jwt.SignerTokenSource{
+				Signer:  signer,
+				Subject: "client",
+			},
+			Audience:      "health",
+			AllowInsecure: true,
+		}))
+
+		stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.subjects).To(Receive(Equal("client")))
+	})
+
+	It("should reject calls without a token as unauthenticated", func() {
+		client := dial()
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+	})
+
+	It("should reject calls with an expired token as unauthenticated", func() {
+		client := dial(grpc.WithPerRPCCredentials(&grpcjwt.Credentials{
+			SignerTokenSource: jwt.SignerTokenSource{
+				Signer:  signer,
+				Subject: "client",
+				Clock:   jwt.NewFakeClock(time.Now().Add(-time.Hour)),
+			},
+			Audience:      "health",
+			AllowInsecure: true,
+		}))
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		Expect(status.Convert(err).Message()).To(Equal("the access token expired"))
+	})
+
+	It("should reject calls failing a rule as permission denied", func() {
+		verifier.Rules = []jwt.Rule{jwt.Equals("role", "admin")}
+
+		client := dial(grpc.WithPerRPCCredentials(&grpcjwt.Credentials{
+			SignerTokenSource: jwt.SignerTokenSource{
+				Signer:  signer,
+				Subject: "client",
+				Claims:  []jwt.Claim{jwt.String("role", "user")},
+			},
+			Audience:      "health",
+			AllowInsecure: true,
+		}))
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("should report lookup failures as unavailable", func() {
+		verifier.Revocations = failingRevocationStore{}
+
+		client := dial(grpc.WithPerRPCCredentials(&grpcjwt.Credentials{
+			SignerTokenSource: jwt.SignerTokenSource{
+				Signer:  signer,
+				Subject: "client",
+				Claims:  []jwt.Claim{jwt.String(jwt.ID, "id")},
+			},
+			Audience:      "health",
+			AllowInsecure: true,
+		}))
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+	It("should require transport security by default", func() {
+		Expect((&grpcjwt.Credentials{}).RequireTransportSecurity()).To(BeTrue())
+	})
+})
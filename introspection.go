@@ -0,0 +1,306 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTokenInactive is the error returned when the issuer reports that an online token is no longer active.
+var ErrTokenInactive = errors.New("token is not active")
+
+// Introspector checks with the issuer whether an online token (the onl claim) is still active.
+type Introspector interface {
+	// Introspect returns true if the issuer reports the token as active.
+	Introspect(ctx context.Context, token []byte) (bool, error)
+}
+
+// IntrospectorFunc is an adapter to allow the use of ordinary functions as an `Introspector`.
+type IntrospectorFunc func(ctx context.Context, token []byte) (bool, error)
+
+// Introspect calls f(ctx, token).
+func (f IntrospectorFunc) Introspect(ctx context.Context, token []byte) (bool, error) {
+	return f(ctx, token)
+}
+
+// IntrospectionClient implements the `Introspector` interface using an RFC 7662 token introspection endpoint.
+type IntrospectionClient struct {
+	// Endpoint is the URL of the introspection endpoint.
+	Endpoint string
+	// ClientID and ClientSecret, when set, are sent as HTTP basic authentication.
+	ClientID     string
+	ClientSecret string
+	// HTTPClient is used to call the endpoint, it defaults to `http.DefaultClient`.
+	HTTPClient *http.Client
+	// CacheTTL, when set, caches active results for the duration, inactive results are never cached.
+	CacheTTL time.Duration
+	// Clock is used to expire cached results, it defaults to the wall clock.
+	Clock Clock
+
+	mu     sync.Mutex
+	active expirySet
+}
+
+// introspectionResponse is the part of the RFC 7662 introspection response used by the client.
+type introspectionResponse struct {
+	Active bool `json:"active"`
+}
+
+// Introspect returns true if the endpoint reports the token as active.
+func (c *IntrospectionClient) Introspect(ctx context.Context, token []byte) (bool, error) {
+	sum := sha256.Sum256(token)
+	key := hex.EncodeToString(sum[:])
+
+	if c.cached(key) {
+		return true, nil
+	}
+
+	active, err := c.introspect(ctx, token)
+	if err != nil || !active {
+		return false, err
+	}
+
+	c.cache(key)
+
+	return true, nil
+}
+
+func (c *IntrospectionClient) introspect(ctx context.Context, token []byte) (bool, error) {
+	form := url.Values{}
+	form.Set("token", string(token))
+	form.Set("token_type_hint", "access_token")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if c.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+		return false, fmt.Errorf("introspection endpoint returned %s", resp.Status)
+	}
+
+	var body introspectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("invalid introspection response: %w", err)
+	}
+
+	return body.Active, nil
+}
+
+// cached returns true if the token hash has a cached active result.
+func (c *IntrospectionClient) cached(key string) bool {
+	if c.CacheTTL <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.active.contains(key, clockOrSystem(c.Clock).Now())
+}
+
+// cache records an active result for the token hash until the cache TTL passes.
+func (c *IntrospectionClient) cache(key string) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active == nil {
+		c.active = expirySet{}
+	}
+
+	now := clockOrSystem(c.Clock).Now()
+	c.active.prune(now)
+	c.active.add(key, now.Add(c.CacheTTL))
+}
+
+// ErrClientUnauthorized is the error returned when an introspection caller fails client authentication.
+var ErrClientUnauthorized = errors.New("client authentication failed")
+
+// ClientAuthenticator authenticates the callers of an `IntrospectionHandler`.
+type ClientAuthenticator interface {
+	// Authenticate returns an error if the request is not from an authorized client.
+	Authenticate(r *http.Request) error
+}
+
+// ClientAuthenticatorFunc is an adapter to allow the use of ordinary functions as a `ClientAuthenticator`.
+type ClientAuthenticatorFunc func(r *http.Request) error
+
+// Authenticate calls f(r).
+func (f ClientAuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// BasicClientAuthenticator implements the `ClientAuthenticator` interface using HTTP basic
+// authentication against a fixed set of client IDs and secrets.
+type BasicClientAuthenticator struct {
+	// Clients maps client IDs to their secrets.
+	Clients map[string]string
+}
+
+// Authenticate returns `ErrClientUnauthorized` if the request does not have the basic
+// authentication credentials of a known client.
+func (a BasicClientAuthenticator) Authenticate(r *http.Request) error {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return ErrClientUnauthorized
+	}
+
+	// credentials are form-encoded before basic authentication (RFC 6749 section 2.3.1).
+	if v, err := url.QueryUnescape(id); err == nil {
+		id = v
+	}
+
+	if v, err := url.QueryUnescape(secret); err == nil {
+		secret = v
+	}
+
+	want, ok := a.Clients[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(secret), []byte(want)) != 1 {
+		return ErrClientUnauthorized
+	}
+
+	return nil
+}
+
+// IntrospectionHandler is an `http.Handler` implementing an RFC 7662 token introspection endpoint.
+// Tokens are checked with the verifier, valid tokens are reported as active with all of their claims,
+// and invalid tokens are only reported as inactive. Tokens that could not be verified because a
+// lookup failed (see `IsLookupError`) receive a 503 `temporarily_unavailable` error instead, so
+// valid tokens are not reported as inactive.
+type IntrospectionHandler struct {
+	// Verifier checks the introspected tokens.
+	Verifier Verifier
+	// Authenticator, when set, authenticates callers, unauthenticated requests are rejected.
+	Authenticator ClientAuthenticator
+}
+
+// ServeHTTP handles an introspection request.
+func (h *IntrospectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeIntrospectionJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "invalid_request"})
+
+		return
+	}
+
+	if h.Authenticator != nil {
+		if err := h.Authenticator.Authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+			writeIntrospectionJSON(w, http.StatusUnauthorized, map[string]interface{}{"error": "invalid_client"})
+
+			return
+		}
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeIntrospectionJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_request"})
+
+		return
+	}
+
+	result, err := VerifyContext(r.Context(), h.Verifier, []byte(token))
+	if IsLookupError(err) {
+		writeIntrospectionJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"error": "temporarily_unavailable"})
+
+		return
+	}
+
+	if err != nil {
+		writeIntrospectionJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+
+		return
+	}
+
+	writeIntrospectionJSON(w, http.StatusOK, introspectionClaims(result))
+}
+
+// introspectionClaims returns the introspection response for a verified token, times are
+// returned as whole seconds and an array scope claim is joined into a space-delimited string.
+func introspectionClaims(result VerifyResult) map[string]interface{} {
+	body := map[string]interface{}{}
+
+	for key, claims := range result.Claims {
+		if len(claims) == 0 {
+			continue
+		}
+
+		switch key {
+		case Audience:
+			aud := make([]string, 0, len(claims))
+			for _, c := range claims {
+				aud = append(aud, c.String)
+			}
+
+			if len(aud) == 1 {
+				body[key] = aud[0]
+			} else {
+				body[key] = aud
+			}
+		case Scope:
+			if scopes, err := claims[0].AsStrings(); err == nil {
+				body[key] = strings.Join(scopes, " ")
+			} else {
+				body[key] = claimValue(claims[0])
+			}
+		default:
+			if claims[0].Type == TimeType {
+				t, _ := claims[0].AsTime()
+				body[key] = t.Unix()
+			} else {
+				body[key] = claimValue(claims[0])
+			}
+		}
+	}
+
+	body["active"] = true
+
+	return body
+}
+
+// writeIntrospectionJSON writes the body as a JSON response with the supplied status code.
+func writeIntrospectionJSON(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,282 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Introspection", func() {
+	var clock *jwt.FakeClock
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var server *httptest.Server
+	var active bool
+	var calls int32
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		signer = createSigner()
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+
+		active = true
+		calls = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "client" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			if r.Method != http.MethodPost || r.PostFormValue("token") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"active": active})
+		}))
+
+		verifier.Introspection = &jwt.IntrospectionClient{
+			Endpoint:     server.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			HTTPClient:   server.Client(),
+			CacheTTL:     time.Minute,
+			Clock:        clock,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should only introspect online tokens", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		active = false
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&calls)).To(BeZero())
+	})
+
+	It("should accept an active online token and cache the result", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", true, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.IsOnline).To(BeTrue())
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+
+		clock.Advance(2 * time.Minute)
+		active = false
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenInactive))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(2)))
+	})
+
+	It("should reject an inactive online token", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", true, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		active = false
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenInactive))
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorRevoked))
+	})
+
+	It("should report endpoint failures as lookup errors", func() {
+		verifier.Introspection = &jwt.IntrospectionClient{
+			Endpoint:   server.URL,
+			HTTPClient: server.Client(),
+		}
+
+		token, err := jwt.Sign(signer, "subject", "audience", true, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(HaveOccurred())
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorLookup))
+	})
+
+	It("should accept an introspector func", func() {
+		verifier.Introspection = jwt.IntrospectorFunc(func(ctx context.Context, token []byte) (bool, error) {
+			return false, nil
+		})
+
+		token, err := jwt.Sign(signer, "subject", "audience", true, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenInactive))
+	})
+})
+
+var _ = Describe("JWT Introspection Handler", func() {
+	var clock *jwt.FakeClock
+	var signer jwt.Signer
+	var handler *jwt.IntrospectionHandler
+
+	introspect := func(token string, user, pass string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		form := url.Values{"token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		body := map[string]interface{}{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+
+		return rec, body
+	}
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		signer = createSigner()
+
+		verifier := createRSAVerifier()
+		verifier.Clock = clock
+		verifier.Schema = jwt.NewClaimSchema().Declare("ttl", jwt.DurationType)
+
+		handler = &jwt.IntrospectionHandler{
+			Verifier: verifier,
+			Authenticator: jwt.BasicClientAuthenticator{
+				Clients: map[string]string{"client": "secret"},
+			},
+		}
+	})
+
+	It("should report a valid token as active with its claims", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Subject, "subject"),
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time(jwt.Expires, clock.Now().Add(time.Hour)),
+			jwt.Any("scope", []string{"read", "write"}),
+			jwt.String("tenant", "acme"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		rec, body := introspect(string(token), "client", "secret")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Cache-Control")).To(Equal("no-store"))
+		Expect(body).To(HaveKeyWithValue("active", true))
+		Expect(body).To(HaveKeyWithValue("sub", "subject"))
+		Expect(body).To(HaveKeyWithValue("aud", "audience"))
+		Expect(body).To(HaveKeyWithValue("exp", float64(clock.Now().Add(time.Hour).Unix())))
+		Expect(body).To(HaveKeyWithValue("scope", "read write"))
+		Expect(body).To(HaveKeyWithValue("tenant", "acme"))
+	})
+
+	It("should report custom numeric claims unchanged", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Float("ratio", 0.75),
+			jwt.Duration("ttl", 90*time.Second),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		rec, body := introspect(string(token), "client", "secret")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("ratio", 0.75))
+		Expect(body).To(HaveKeyWithValue("ttl", 90.0))
+	})
+
+	It("should only report an invalid token as inactive", func() {
+		token, err := jwt.Sign(signer, "subject", "other", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		rec, body := introspect(string(token), "client", "secret")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(body).To(Equal(map[string]interface{}{"active": false}))
+	})
+
+	It("should not report a token as inactive when a lookup fails", func() {
+		handler.Verifier.(*jwt.RSAVerifier).Revocations = failingRevocationStore{}
+
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		rec, body := introspect(string(token), "client", "secret")
+		Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(Equal(map[string]interface{}{"error": "temporarily_unavailable"}))
+	})
+
+	It("should reject unauthenticated callers", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		rec, body := introspect(string(token), "client", "wrong")
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix("Basic"))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_client"))
+
+		rec, _ = introspect(string(token), "", "")
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject requests without a token", func() {
+		rec, body := introspect("", "client", "secret")
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_request"))
+	})
+
+	It("should be usable by the introspection client", func() {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		client := &jwt.IntrospectionClient{
+			Endpoint:     server.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			HTTPClient:   server.Client(),
+		}
+
+		token, err := jwt.Sign(signer, "subject", "audience", true, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		active, err := client.Introspect(context.Background(), token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(active).To(BeTrue())
+
+		clock.Advance(2 * time.Hour)
+
+		active, err = client.Introspect(context.Background(), token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(active).To(BeFalse())
+	})
+})
@@ -0,0 +1,311 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrTokenNotFound is the error returned when a request does not carry a token.
+var ErrTokenNotFound = errors.New("token not found in request")
+
+// ErrTokenRequestInvalid is the error returned when a request carries a token in an invalid way
+// (eg. more than one token, or a malformed Authorization header).
+var ErrTokenRequestInvalid = errors.New("invalid token request")
+
+// TokenExtractor takes the token from an HTTP request.
+type TokenExtractor interface {
+	// Extract returns the token, or `ErrTokenNotFound` if the request does not carry one.
+	Extract(r *http.Request) ([]byte, error)
+}
+
+// TokenExtractorFunc is an adapter to allow the use of ordinary functions as a `TokenExtractor`.
+type TokenExtractorFunc func(r *http.Request) ([]byte, error)
+
+// Extract calls f(r).
+func (f TokenExtractorFunc) Extract(r *http.Request) ([]byte, error) {
+	return f(r)
+}
+
+// AuthorizationExtractor returns a `TokenExtractor` that takes the token from the Authorization
+// header with the supplied scheme (eg. `Bearer`), the scheme is matched case-insensitively.
+func AuthorizationExtractor(scheme string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		values := r.Header.Values("Authorization")
+		if len(values) == 0 {
+			return nil, ErrTokenNotFound
+		}
+
+		if len(values) > 1 {
+			return nil, fmt.Errorf("%w: multiple Authorization headers", ErrTokenRequestInvalid)
+		}
+
+		parts := strings.SplitN(values[0], " ", 2)
+		if !strings.EqualFold(parts[0], scheme) {
+			return nil, ErrTokenNotFound
+		}
+
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("%w: malformed Authorization header", ErrTokenRequestInvalid)
+		}
+
+		return []byte(strings.TrimSpace(parts[1])), nil
+	})
+}
+
+// BearerExtractor returns a `TokenExtractor` that takes the token from an `Authorization: Bearer` header.
+func BearerExtractor() TokenExtractor {
+	return AuthorizationExtractor("Bearer")
+}
+
+// DPoPExtractor returns a `TokenExtractor` that takes a DPoP-bound token from an
+// `Authorization: DPoP` header (RFC 9449), requests without a DPoP proof header have no token.
+func DPoPExtractor() TokenExtractor {
+	extractor := AuthorizationExtractor("DPoP")
+
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		if r.Header.Get(DPoPHeader) == "" {
+			return nil, ErrTokenNotFound
+		}
+
+		return extractor.Extract(r)
+	})
+}
+
+// HeaderExtractor returns a `TokenExtractor` that takes the token from the supplied header.
+func HeaderExtractor(name string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		if v := r.Header.Get(name); v != "" {
+			return []byte(v), nil
+		}
+
+		return nil, ErrTokenNotFound
+	})
+}
+
+// CookieExtractor returns a `TokenExtractor` that takes the token from the supplied cookie.
+func CookieExtractor(name string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return []byte(c.Value), nil
+		}
+
+		return nil, ErrTokenNotFound
+	})
+}
+
+// QueryExtractor returns a `TokenExtractor` that takes the token from the supplied query parameter
+// (eg. `access_token`). Tokens in URLs end up in logs and browser history, so prefer a header or cookie.
+func QueryExtractor(name string) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		if v := r.URL.Query().Get(name); v != "" {
+			return []byte(v), nil
+		}
+
+		return nil, ErrTokenNotFound
+	})
+}
+
+// FirstExtractor returns a `TokenExtractor` that tries each of the supplied extractors in order
+// and returns the first token found.
+func FirstExtractor(extractors ...TokenExtractor) TokenExtractor {
+	return TokenExtractorFunc(func(r *http.Request) ([]byte, error) {
+		for _, e := range extractors {
+			token, err := e.Extract(r)
+			if !errors.Is(err, ErrTokenNotFound) {
+				return token, err
+			}
+		}
+
+		return nil, ErrTokenNotFound
+	})
+}
+
+// resultKey is the context key for the `VerifyResult` of a request.
+type resultKey struct{}
+
+// ContextWithResult returns a copy of the context holding the verified result.
+func ContextWithResult(ctx context.Context, result VerifyResult) context.Context {
+	return context.WithValue(ctx, resultKey{}, result)
+}
+
+// ResultFromContext returns the verified result held by the context, if any.
+func ResultFromContext(ctx context.Context) (VerifyResult, bool) {
+	result, ok := ctx.Value(resultKey{}).(VerifyResult)
+
+	return result, ok
+}
+
+// SubjectFromContext returns the subject of the verified result held by the context, if any.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	result, ok := ResultFromContext(ctx)
+
+	return result.Subject, ok
+}
+
+// Middleware authenticates HTTP requests, the token is taken from the request and verified,
+// and the result is stored in the request context (see `ResultFromContext`).
+//
+// The TLS connection state and any DPoP proof of the request are passed to the verifier,
+// see `ContextWithConnectionState` and `ContextWithDPoPRequest`.
+type Middleware struct {
+	// Verifier checks the tokens.
+	Verifier Verifier
+	// Extractor takes the token from the request, it defaults to `BearerExtractor` followed by
+	// `DPoPExtractor`.
+	Extractor TokenExtractor
+	// Realm is the realm of the WWW-Authenticate challenge.
+	Realm string
+	// Optional makes requests without a token pass through unauthenticated, requests
+	// with an invalid token are still rejected.
+	Optional bool
+	// ErrorHandler, when set, writes the response for rejected requests, it defaults to
+	// an RFC 6750 error response.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// realmKey is the context key for the realm of the `Middleware` that authenticated a request.
+type realmKey struct{}
+
+// realmFromContext returns the realm of the `Middleware` that authenticated the request, if any.
+func realmFromContext(ctx context.Context) string {
+	realm, _ := ctx.Value(realmKey{}).(string)
+
+	return realm
+}
+
+// Handler returns an `http.Handler` that authenticates requests before calling next.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.Realm != "" {
+			r = r.WithContext(context.WithValue(r.Context(), realmKey{}, m.Realm))
+		}
+
+		result, err := m.authenticate(r)
+		if err != nil {
+			if m.Optional && errors.Is(err, ErrTokenNotFound) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if m.ErrorHandler != nil {
+				m.ErrorHandler(w, r, err)
+			} else {
+				WriteBearerError(w, m.Realm, err)
+			}
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithResult(r.Context(), result)))
+	})
+}
+
+// authenticate extracts and verifies the token of the request.
+func (m *Middleware) authenticate(r *http.Request) (VerifyResult, error) {
+	extractor := m.Extractor
+	if extractor == nil {
+		extractor = FirstExtractor(BearerExtractor(), DPoPExtractor())
+	}
+
+	token, err := extractor.Extract(r)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+
+	ctx := ContextWithConnectionState(r.Context(), r.TLS)
+	ctx = ContextWithDPoPRequest(ctx, r)
+
+	return VerifyContext(ctx, m.Verifier, token)
+}
+
+// WriteBearerError writes an RFC 6750 error response with a WWW-Authenticate challenge for an
+// authentication error. Requests without a token receive a challenge without an error code,
+// requests with an invalid token an `invalid_token` error, and valid tokens that only failed a
+// claim rule or lack a required scope a 403 `insufficient_scope` error. Tokens that could not be verified
+// because a lookup failed (see `IsLookupError`) receive a 503 without a challenge, so clients
+// do not discard them. DPoP proof and binding failures receive an RFC 9449 DPoP challenge instead.
+func WriteBearerError(w http.ResponseWriter, realm string, err error) {
+	switch {
+	case IsLookupError(err):
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	case errors.Is(err, ErrTokenNotFound):
+		writeBearerChallenge(w, http.StatusUnauthorized, realm, "", "")
+	case errors.Is(err, ErrTokenRequestInvalid):
+		writeBearerChallenge(w, http.StatusBadRequest, realm, "invalid_request", "the request is malformed")
+	case errors.Is(err, ErrDPoPProofInvalid), errors.Is(err, ErrDPoPProofReplayed):
+		writeChallenge(w, http.StatusUnauthorized, "DPoP", realm, "invalid_dpop_proof", "the DPoP proof is invalid", dpopAlgs)
+	case errors.Is(err, ErrTokenDPoPMismatch):
+		writeChallenge(
+			w,
+			http.StatusUnauthorized,
+			"DPoP",
+			realm,
+			"invalid_token",
+			"the access token is not bound to the DPoP key",
+			dpopAlgs,
+		)
+	case isForbidden(err):
+		description := "the access token is not permitted to access the resource"
+		if errors.Is(err, ErrInsufficientScope) {
+			description = "the access token does not have the required scope"
+		}
+
+		writeBearerChallenge(w, http.StatusForbidden, realm, "insufficient_scope", description)
+	case errors.Is(err, ErrTokenExpired):
+		writeBearerChallenge(w, http.StatusUnauthorized, realm, "invalid_token", "the access token expired")
+	default:
+		writeBearerChallenge(w, http.StatusUnauthorized, realm, "invalid_token", "the access token is invalid")
+	}
+}
+
+// isForbidden returns true if the error is only caused by a claim rule or a missing scope,
+// so the token is valid but not permitted to access the resource.
+func isForbidden(err error) bool {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return verr.Flags == ValidationErrorClaim
+	}
+
+	return errors.Is(err, ErrInsufficientScope)
+}
+
+// dpopAlgs is the algs parameter of a DPoP challenge, the algorithms `DPoPVerifier` accepts for proofs.
+const dpopAlgs = `algs="ES256 ES384 ES512"`
+
+// writeBearerChallenge writes the status code with a Bearer WWW-Authenticate challenge, the
+// error code and description are omitted when empty, params are appended to the challenge.
+func writeBearerChallenge(w http.ResponseWriter, status int, realm, code, description string, params ...string) {
+	writeChallenge(w, status, "Bearer", realm, code, description, params...)
+}
+
+// writeChallenge writes the status code with a WWW-Authenticate challenge for the scheme, see
+// `writeBearerChallenge`.
+func writeChallenge(w http.ResponseWriter, status int, scheme, realm, code, description string, params ...string) {
+	challenge := []string{}
+
+	if realm != "" {
+		challenge = append(challenge, fmt.Sprintf("realm=%q", realm))
+	}
+
+	if code != "" {
+		challenge = append(challenge, fmt.Sprintf("error=%q", code))
+	}
+
+	if description != "" {
+		challenge = append(challenge, fmt.Sprintf("error_description=%q", description))
+	}
+
+	challenge = append(challenge, params...)
+
+	header := scheme
+	if len(challenge) > 0 {
+		header += " " + strings.Join(challenge, ", ")
+	}
+
+	w.Header().Set("WWW-Authenticate", header)
+	http.Error(w, http.StatusText(status), status)
+}
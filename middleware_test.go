@@ -0,0 +1,161 @@
+package jwt_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Middleware", func() {
+	var signer jwt.Signer
+	var middleware *jwt.Middleware
+	var handler http.Handler
+	var token []byte
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	BeforeEach(func() {
+		signer = createSigner()
+
+		middleware = &jwt.Middleware{
+			Verifier: createVerifier(),
+			Realm:    "api",
+		}
+
+		handler = middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, ok := jwt.SubjectFromContext(r.Context())
+			if !ok {
+				subject = "anonymous"
+			}
+
+			_, _ = w.Write([]byte(subject))
+		}))
+
+		var err error
+		token, err = jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should authenticate a bearer token and store the result in the context", func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+string(token))
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal("subject"))
+	})
+
+	It("should challenge requests without a token", func() {
+		rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="api"`))
+	})
+
+	It("should reject requests with an invalid token", func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(
+			Equal(`Bearer realm="api", error="invalid_token", error_description="the access token is invalid"`),
+		)
+	})
+
+	It("should report expired tokens", func() {
+		expired, err := jwt.Sign(signer, "subject", "audience", false, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+string(expired))
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error_description="the access token expired"`))
+	})
+
+	It("should not reject tokens when a lookup fails", func() {
+		verifier := createRSAVerifier()
+		verifier.Revocations = failingRevocationStore{}
+		middleware.Verifier = verifier
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+string(token))
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(BeEmpty())
+	})
+
+	It("should not reject tokens when the request is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer "+string(token))
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should reject malformed Authorization headers", func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer ")
+
+		rec := serve(req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error="invalid_request"`))
+	})
+
+	It("should let requests without a token through when optional", func() {
+		middleware.Optional = true
+
+		rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal("anonymous"))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		Expect(serve(req).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should use the configured extractors", func() {
+		middleware.Extractor = jwt.FirstExtractor(
+			jwt.BearerExtractor(),
+			jwt.CookieExtractor("token"),
+			jwt.QueryExtractor("access_token"),
+			jwt.HeaderExtractor("X-Token"),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: string(token)})
+		Expect(serve(req).Body.String()).To(Equal("subject"))
+
+		req = httptest.NewRequest(http.MethodGet, "/?access_token="+string(token), nil)
+		Expect(serve(req).Body.String()).To(Equal("subject"))
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Token", string(token))
+		Expect(serve(req).Body.String()).To(Equal("subject"))
+	})
+
+	It("should use the error handler", func() {
+		middleware.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusTeapot)
+		}
+
+		rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+		Expect(rec.Code).To(Equal(http.StatusTeapot))
+		Expect(rec.Body.String()).To(ContainSubstring(jwt.ErrTokenNotFound.Error()))
+	})
+})
@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+// Confirmation is the claim for the proof-of-possession confirmation methods of a token (RFC 7800).
+const Confirmation string = "cnf"
+
+// CertificateThumbprintMember is the `Confirmation` member holding the SHA-256 thumbprint of the
+// client certificate a token is bound to (RFC 8705).
+const CertificateThumbprintMember string = "x5t#S256"
+
+// ErrTokenCertificateMismatch is the error returned when a certificate-bound token is not presented
+// over a TLS connection with the client certificate it was issued to.
+var ErrTokenCertificateMismatch = errors.New("token is not bound to the client certificate")
+
+// CertificateThumbprint returns the base64url encoded SHA-256 thumbprint of the DER encoded certificate.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CertificateConfirmation returns a cnf claim binding a token to the client certificate.
+func CertificateConfirmation(cert *x509.Certificate) Claim {
+	return Reflect(Confirmation, map[string]interface{}{
+		CertificateThumbprintMember: CertificateThumbprint(cert),
+	})
+}
+
+// SignCertificateBound takes a signer, a client certificate and a list of claims and produces a
+// signed token that is only valid when presented with the client certificate.
+func SignCertificateBound(signer Signer, cert *x509.Certificate, claims ...Claim) ([]byte, error) {
+	return signer.SignClaims(append(claims, CertificateConfirmation(cert))...)
+}
+
+// VerifyCertificateBinding returns `ErrTokenCertificateMismatch` if the cnf claim of the verified
+// token does not match the peer certificate of the TLS connection.
+func VerifyCertificateBinding(result VerifyResult, state *tls.ConnectionState) error {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ErrTokenCertificateMismatch
+	}
+
+	cnf, err := result.GetMap(Confirmation)
+	if err != nil {
+		return ErrTokenCertificateMismatch
+	}
+
+	want, ok := cnf[CertificateThumbprintMember].(string)
+	if !ok {
+		return ErrTokenCertificateMismatch
+	}
+
+	got := CertificateThumbprint(state.PeerCertificates[0])
+	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return ErrTokenCertificateMismatch
+	}
+
+	return nil
+}
+
+// connectionStateKey is the context key for the TLS connection state.
+type connectionStateKey struct{}
+
+// ContextWithConnectionState returns a copy of the context holding the TLS connection state the
+// token was presented over (eg. `http.Request.TLS`), it is used by verifiers to check certificate-bound tokens.
+func ContextWithConnectionState(ctx context.Context, state *tls.ConnectionState) context.Context {
+	return context.WithValue(ctx, connectionStateKey{}, state)
+}
+
+// ConnectionStateFromContext returns the TLS connection state held by the context, if any.
+func ConnectionStateFromContext(ctx context.Context) (*tls.ConnectionState, bool) {
+	state, ok := ctx.Value(connectionStateKey{}).(*tls.ConnectionState)
+
+	return state, ok && state != nil
+}
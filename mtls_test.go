@@ -0,0 +1,122 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func createClientCertificate(name string) *x509.Certificate {
+	privateKey, err := jwt.ParsePKCS1PrivateKeyFromFileAFS(createAfs(), "key.pem")
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return cert
+}
+
+var _ = Describe("JWT Certificate Binding", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var cert *x509.Certificate
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createRSAVerifier()
+		verifier.CertificateBound = true
+		cert = createClientCertificate("client")
+	})
+
+	It("should compute the thumbprint of a certificate", func() {
+		block, _ := pem.Decode([]byte(rsaPublicKey))
+		Expect(block).NotTo(BeNil())
+
+		signerCert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(jwt.CertificateThumbprint(signerCert)).To(HaveLen(43))
+		Expect(jwt.CertificateThumbprint(signerCert)).NotTo(Equal(jwt.CertificateThumbprint(cert)))
+	})
+
+	It("should accept a token presented with its certificate", func() {
+		token, err := jwt.SignCertificateBound(
+			signer,
+			cert,
+			jwt.String(jwt.Subject, "subject"),
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time(jwt.Expires, time.Now().Add(time.Hour)),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithConnectionState(context.Background(), &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+		})
+
+		result, err := verifier.VerifyContext(ctx, token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+	})
+
+	It("should reject a token presented with another certificate", func() {
+		token, err := jwt.SignCertificateBound(signer, cert, jwt.String(jwt.Audience, "audience"))
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithConnectionState(context.Background(), &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{createClientCertificate("other")},
+		})
+
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrTokenCertificateMismatch))
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorBinding))
+	})
+
+	It("should reject a token presented without a certificate", func() {
+		token, err := jwt.SignCertificateBound(signer, cert, jwt.String(jwt.Audience, "audience"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenCertificateMismatch))
+
+		ctx := jwt.ContextWithConnectionState(context.Background(), &tls.ConnectionState{})
+
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrTokenCertificateMismatch))
+	})
+
+	It("should reject a token that is not certificate-bound", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := jwt.ContextWithConnectionState(context.Background(), &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+		})
+
+		_, err = verifier.VerifyContext(ctx, token)
+		Expect(err).To(MatchError(jwt.ErrTokenCertificateMismatch))
+	})
+})
@@ -0,0 +1,754 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrPolicyInvalid is the error returned when a policy expression can not be compiled.
+var ErrPolicyInvalid = errors.New("invalid policy")
+
+// ErrPolicyEvaluation is the error returned when a policy expression can not be evaluated
+// (eg. comparing a string with a number).
+var ErrPolicyEvaluation = errors.New("policy evaluation failed")
+
+// Policy is a compiled boolean expression over the claims of a verified token and the attributes
+// of a request, eg.
+//
+//	claims.tenant == request.tenant && (claims.role == "admin" || claims.sub == request.owner)
+//
+// Values are referenced with `claims.<name>` and `request.<name>`, nested objects with further
+// `.<name>` or `["<name>"]` selectors, missing values are `null`. Literals are strings (single or
+// double quoted), numbers, `true`, `false`, `null` and lists (`["a", "b"]`).
+//
+// The operators are `==`, `!=`, `<`, `<=`, `>`, `>=` (numbers or strings), `in` (list membership,
+// a string right-hand side is treated as a space-delimited list, eg. a scope), `!`, `&&` and `||`.
+// Claims with multiple values (eg. audiences) are lists.
+//
+// Comparisons with a missing value are false, so that missing claims or attributes never grant
+// access, except `==` and `!=` comparisons with the `null` literal (eg. `claims.role != null`).
+type Policy struct {
+	source string
+	root   policyNode
+}
+
+// CompilePolicy compiles a policy expression, returning `ErrPolicyInvalid` for syntax errors and
+// unknown references. When attributes are supplied, only those request attributes may be referenced.
+func CompilePolicy(source string, attributes ...string) (*Policy, error) {
+	tokens, err := lexPolicy(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &policyParser{tokens: tokens}
+	if len(attributes) > 0 {
+		p.attributes = map[string]bool{}
+		for _, a := range attributes {
+			p.attributes[a] = true
+		}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != policyEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+
+	return &Policy{source: source, root: root}, nil
+}
+
+// MustCompilePolicy is the same as CompilePolicy, but panics if the expression can not be compiled.
+// It is intended for policies declared in package variables.
+func MustCompilePolicy(source string, attributes ...string) *Policy {
+	p, err := CompilePolicy(source, attributes...)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// String returns the source of the policy.
+func (p *Policy) String() string {
+	return p.source
+}
+
+// Evaluate returns the result of the policy for the verified token and request attributes.
+func (p *Policy) Evaluate(result VerifyResult, attributes map[string]interface{}) (bool, error) {
+	env := policyEnv{
+		claims:     result.Claims,
+		attributes: attributes,
+	}
+
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: policy did not return a boolean", ErrPolicyEvaluation)
+	}
+
+	return b, nil
+}
+
+// Validate implements the `Rule` interface, policies used as rules are evaluated without request
+// attributes. A policy that does not pass returns `ErrTokenClaimInvalid`.
+func (p *Policy) Validate(result VerifyResult) error {
+	ok, err := p.Evaluate(result, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTokenClaimInvalid, err)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: policy %q did not pass", ErrTokenClaimInvalid, p.source)
+	}
+
+	return nil
+}
+
+// RequirePolicy returns middleware that requires the policy to pass for the token verified by a
+// `Middleware` (see `ResultFromContext`) and the request attributes returned by attributes, which
+// may be nil. It responds with 401 to requests without a verified token and 403 when the policy
+// does not pass.
+func RequirePolicy(
+	policy *Policy,
+	attributes func(r *http.Request) map[string]interface{},
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			realm := realmFromContext(r.Context())
+
+			result, ok := ResultFromContext(r.Context())
+			if !ok {
+				WriteBearerError(w, realm, ErrTokenNotFound)
+
+				return
+			}
+
+			var attrs map[string]interface{}
+			if attributes != nil {
+				attrs = attributes(r)
+			}
+
+			if ok, err := policy.Evaluate(result, attrs); err != nil || !ok {
+				writeBearerChallenge(
+					w,
+					http.StatusForbidden,
+					realm,
+					"insufficient_scope",
+					"the access token is not permitted to access the resource",
+				)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// policyEnv holds the values a policy is evaluated against.
+type policyEnv struct {
+	claims     map[string][]Claim
+	attributes map[string]interface{}
+}
+
+// policyNode is a node of a compiled policy expression.
+type policyNode interface {
+	eval(env policyEnv) (interface{}, error)
+}
+
+type policyLiteral struct {
+	value interface{}
+}
+
+func (n policyLiteral) eval(env policyEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type policyList struct {
+	items []policyNode
+}
+
+func (n policyList) eval(env policyEnv) (interface{}, error) {
+	list := make([]interface{}, 0, len(n.items))
+
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, v)
+	}
+
+	return list, nil
+}
+
+// policyReference is a `claims` or `request` reference followed by a path of object keys.
+type policyReference struct {
+	root string
+	path []string
+}
+
+func (n policyReference) eval(env policyEnv) (interface{}, error) {
+	var v interface{}
+
+	if n.root == "claims" {
+		v = policyClaimValue(env.claims[n.path[0]])
+	} else {
+		v = normalizePolicyValue(env.attributes[n.path[0]])
+	}
+
+	for _, key := range n.path[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+
+		v = normalizePolicyValue(m[key])
+	}
+
+	return v, nil
+}
+
+type policyNot struct {
+	operand policyNode
+}
+
+func (n policyNot) eval(env policyEnv) (interface{}, error) {
+	b, err := evalPolicyBool(n.operand, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return !b, nil
+}
+
+// policyLogical is a short-circuit `&&` or `||` expression.
+type policyLogical struct {
+	op          string
+	left, right policyNode
+}
+
+func (n policyLogical) eval(env policyEnv) (interface{}, error) {
+	left, err := evalPolicyBool(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if (n.op == "&&" && !left) || (n.op == "||" && left) {
+		return left, nil
+	}
+
+	return evalPolicyBool(n.right, env)
+}
+
+type policyCompare struct {
+	op          string
+	left, right policyNode
+}
+
+func (n policyCompare) eval(env policyEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		if (left == nil || right == nil) && !isPolicyNull(n.left) && !isPolicyNull(n.right) {
+			return false, nil
+		}
+
+		return reflect.DeepEqual(left, right) == (n.op == "=="), nil
+	case "in":
+		return policyIn(left, right), nil
+	}
+
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: can not compare %v %s %v", ErrPolicyEvaluation, left, n.op, right)
+		}
+
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: can not compare %v %s %v", ErrPolicyEvaluation, left, n.op, right)
+		}
+
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("%w: can not compare %v %s %v", ErrPolicyEvaluation, left, n.op, right)
+	}
+
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// isPolicyNull returns true if the node is the `null` literal.
+func isPolicyNull(n policyNode) bool {
+	l, ok := n.(policyLiteral)
+
+	return ok && l.value == nil
+}
+
+// evalPolicyBool evaluates a node that must return a boolean.
+func evalPolicyBool(n policyNode, env policyEnv) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %v is not a boolean", ErrPolicyEvaluation, v)
+	}
+
+	return b, nil
+}
+
+// policyIn returns true if the value is an element of the list, a string list is treated as
+// space-delimited. A missing value is never an element.
+func policyIn(value, list interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	switch l := list.(type) {
+	case []interface{}:
+		for _, v := range l {
+			if reflect.DeepEqual(v, value) {
+				return true
+			}
+		}
+	case string:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+
+		for _, f := range strings.Fields(l) {
+			if f == s {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// policyClaimValue returns the value of the claims for a key, multiple claims are returned as a list.
+func policyClaimValue(claims []Claim) interface{} {
+	switch len(claims) {
+	case 0:
+		return nil
+	case 1:
+		return normalizePolicyValue(ruleValue(claims[0]))
+	}
+
+	list := make([]interface{}, 0, len(claims))
+	for _, c := range claims {
+		list = append(list, normalizePolicyValue(ruleValue(c)))
+	}
+
+	return list
+}
+
+// normalizePolicyValue converts numbers to float64 and slices to []interface{} so that values
+// from claims, request attributes and literals compare equal.
+func normalizePolicyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, bool, string, float64, map[string]interface{}:
+		return val
+	case []interface{}:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			list = append(list, normalizePolicyValue(item))
+		}
+
+		return list
+	case []string:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			list = append(list, item)
+		}
+
+		return list
+	case fmt.Stringer:
+		return val.String()
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	default:
+		return v
+	}
+}
+
+type policyTokenKind int
+
+const (
+	policyEOF policyTokenKind = iota
+	policyIdent
+	policyString
+	policyNumber
+	policyOperator
+)
+
+// policyToken is a lexical token of a policy expression.
+type policyToken struct {
+	kind  policyTokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// String returns a description of the token for error messages.
+func (t policyToken) String() string {
+	if t.kind == policyEOF {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+// policyOperators are the operators of the expression language, longest first.
+var policyOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+// lexPolicy splits a policy expression into tokens.
+//
+//nolint:gocyclo
+func lexPolicy(source string) ([]policyToken, error) {
+	tokens := []policyToken{}
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			i++
+
+			var sb strings.Builder
+
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+
+				sb.WriteRune(runes[i])
+			}
+
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrPolicyInvalid, start)
+			}
+
+			i++
+
+			tokens = append(tokens, policyToken{kind: policyString, text: string(runes[start:i]), value: sb.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			f, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid number %q at %d", ErrPolicyInvalid, string(runes[start:i]), start)
+			}
+
+			tokens = append(tokens, policyToken{kind: policyNumber, text: string(runes[start:i]), value: f, pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, policyToken{kind: policyIdent, text: string(runes[start:i]), pos: start})
+		default:
+			op := ""
+
+			for _, candidate := range policyOperators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+
+					break
+				}
+			}
+
+			if op == "" {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrPolicyInvalid, string(r), i)
+			}
+
+			tokens = append(tokens, policyToken{kind: policyOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, policyToken{kind: policyEOF, pos: len(runes)}), nil
+}
+
+// policyParser is a recursive descent parser for policy expressions.
+type policyParser struct {
+	tokens     []policyToken
+	pos        int
+	attributes map[string]bool
+}
+
+func (p *policyParser) peek() policyToken {
+	return p.tokens[p.pos]
+}
+
+func (p *policyParser) next() policyToken {
+	t := p.tokens[p.pos]
+	if t.kind != policyEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is the supplied operator or keyword.
+func (p *policyParser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == policyOperator || t.kind == policyIdent) && t.text == text {
+		p.pos++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *policyParser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+
+		return p.errorf(t, "expected %q, found %s", text, t)
+	}
+
+	return nil
+}
+
+func (p *policyParser) errorf(t policyToken, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at %d", ErrPolicyInvalid, fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = policyLogical{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = policyLogical{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *policyParser) parseNot() (policyNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return policyNot{operand: operand}, nil
+	}
+
+	return p.parseCompare()
+}
+
+func (p *policyParser) parseCompare() (policyNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.accept(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+
+			return policyCompare{op: op, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+//nolint:gocyclo
+func (p *policyParser) parseOperand() (policyNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case policyString, policyNumber:
+		return policyLiteral{value: t.value}, nil
+	case policyIdent:
+		switch t.text {
+		case "true":
+			return policyLiteral{value: true}, nil
+		case "false":
+			return policyLiteral{value: false}, nil
+		case "null":
+			return policyLiteral{value: nil}, nil
+		case "claims", "request":
+			return p.parseReference(t)
+		default:
+			return nil, p.errorf(t, "unknown identifier %q, expected claims or request", t.text)
+		}
+	case policyOperator:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			return n, p.expect(")")
+		case "[":
+			return p.parseList()
+		}
+	}
+
+	return nil, p.errorf(t, "unexpected %s", t)
+}
+
+func (p *policyParser) parseList() (policyNode, error) {
+	list := policyList{}
+
+	if p.accept("]") {
+		return list, nil
+	}
+
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		list.items = append(list.items, item)
+
+		if p.accept("]") {
+			return list, nil
+		}
+
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *policyParser) parseReference(root policyToken) (policyNode, error) {
+	ref := policyReference{root: root.text}
+
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != policyIdent {
+				return nil, p.errorf(t, "expected a name after %q", ".")
+			}
+
+			ref.path = append(ref.path, t.text)
+		case p.accept("["):
+			t := p.next()
+			if t.kind != policyString {
+				return nil, p.errorf(t, "expected a quoted name after %q", "[")
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+			ref.path = append(ref.path, t.value.(string))
+		default:
+			if len(ref.path) == 0 {
+				return nil, p.errorf(root, "%s must be followed by a name", root.text)
+			}
+
+			if ref.root == "request" && p.attributes != nil && !p.attributes[ref.path[0]] {
+				return nil, p.errorf(root, "unknown request attribute %q", ref.path[0])
+			}
+
+			return ref, nil
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Policies", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+
+	verify := func(claims ...jwt.Claim) jwt.VerifyResult {
+		token, err := signer.SignClaims(append(claims, jwt.String(jwt.Audience, "audience"))...)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createRSAVerifier()
+	})
+
+	table.DescribeTable("compile errors",
+		func(source string) {
+			_, err := jwt.CompilePolicy(source, "tenant", "owner")
+			Expect(err).To(MatchError(jwt.ErrPolicyInvalid))
+		},
+		table.Entry("empty", ""),
+		table.Entry("unknown root", `claim.role == "admin"`),
+		table.Entry("unknown attribute", `claims.tenant == request.tenent`),
+		table.Entry("missing name", `claims == "admin"`),
+		table.Entry("unterminated string", `claims.role == "admin`),
+		table.Entry("unbalanced parentheses", `(claims.role == "admin"`),
+		table.Entry("trailing operator", `claims.role == "admin" &&`),
+		table.Entry("single ampersand", `claims.role == "admin" & true`),
+		table.Entry("unquoted selector", `claims[role] == "admin"`),
+		table.Entry("trailing tokens", `claims.role == "admin" "user"`),
+	)
+
+	It("should panic when a policy can not be compiled", func() {
+		Expect(func() { jwt.MustCompilePolicy(`claims.role ==`) }).To(Panic())
+		Expect(jwt.MustCompilePolicy(`claims.role == "admin"`).String()).To(Equal(`claims.role == "admin"`))
+	})
+
+	table.DescribeTable("evaluation",
+		func(source string, expected bool) {
+			result := verify(
+				jwt.String(jwt.Subject, "alice"),
+				jwt.String("tenant", "acme"),
+				jwt.String("role", "editor"),
+				jwt.Int("level", 3),
+				jwt.Bool("verified", true),
+				jwt.String(jwt.Scope, "orders:read users:read"),
+				jwt.Any("groups", []string{"staff", "ops"}),
+				jwt.Any("address", map[string]interface{}{"country": "AU"}),
+			)
+
+			attributes := map[string]interface{}{
+				"tenant": "acme",
+				"owner":  "bob",
+				"size":   2,
+				"tags":   []string{"draft"},
+			}
+
+			ok, err := jwt.MustCompilePolicy(source).Evaluate(result, attributes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(Equal(expected))
+		},
+		table.Entry("equal attribute", `claims.tenant == request.tenant`, true),
+		table.Entry(
+			"combined",
+			`claims.tenant == request.tenant && (claims.role == "admin" || claims.sub == request.owner)`,
+			false,
+		),
+		table.Entry("not equal", `claims.sub != request.owner`, true),
+		table.Entry("single quotes", `claims.role == 'editor'`, true),
+		table.Entry("number comparison", `claims.level >= 3 && claims.level < 4`, true),
+		table.Entry("number attribute", `request.size < claims.level`, true),
+		table.Entry("string ordering", `claims.role > "admin"`, true),
+		table.Entry("boolean claim", `claims.verified`, true),
+		table.Entry("negation", `!claims.verified || claims.level > 5`, false),
+		table.Entry("list literal", `claims.role in ["admin", "editor"]`, true),
+		table.Entry("array claim", `"ops" in claims.groups`, true),
+		table.Entry("array attribute", `"draft" in request.tags`, true),
+		table.Entry("space-delimited claim", `"orders:read" in claims.scope`, true),
+		table.Entry("partial space-delimited claim", `"orders" in claims.scope`, false),
+		table.Entry("nested claim", `claims.address.country == "AU"`, true),
+		table.Entry("quoted selector", `claims["address"]["country"] == "AU"`, true),
+		table.Entry("missing claim", `claims.missing == null`, true),
+		table.Entry("missing nested claim", `claims.tenant.name == null`, true),
+		table.Entry("missing ordering", `claims.missing > 1`, false),
+		table.Entry("short-circuit", `claims.missing != null && claims.missing > "a"`, false),
+		table.Entry("present claim", `claims.role != null`, true),
+		table.Entry("missing claim and attribute", `claims.missing == request.missing`, false),
+		table.Entry("missing claim not equal", `claims.missing != request.owner`, false),
+		table.Entry("missing attribute", `claims.tenant == request.missing`, false),
+		table.Entry("missing attribute not equal", `claims.sub != request.missing`, false),
+		table.Entry("missing nested claims", `claims.address.city == claims.tenant.city`, false),
+		table.Entry("missing in list", `claims.missing in [request.missing, "admin"]`, false),
+		table.Entry("in missing list", `"ops" in claims.missing`, false),
+	)
+
+	table.DescribeTable("evaluation errors",
+		func(source string) {
+			result := verify(jwt.String("role", "editor"), jwt.Int("level", 3))
+
+			_, err := jwt.MustCompilePolicy(source).Evaluate(result, nil)
+			Expect(err).To(MatchError(jwt.ErrPolicyEvaluation))
+		},
+		table.Entry("mixed ordering", `claims.level > "2"`),
+		table.Entry("non-boolean logical operand", `claims.role && true`),
+		table.Entry("non-boolean result", `claims.role`),
+	)
+
+	It("should validate policies as rules", func() {
+		verifier.Rules = []jwt.Rule{jwt.MustCompilePolicy(`claims.role in ["admin", "editor"]`)}
+
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String("role", "viewer"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenClaimInvalid))
+
+		token, err = signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String("role", "admin"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("with middleware", func() {
+		var handler http.Handler
+
+		serve := func(path string, tenant string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+
+			if tenant != "" {
+				token, err := signer.SignClaims(
+					jwt.String(jwt.Audience, "audience"),
+					jwt.Time(jwt.Expires, time.Now().Add(time.Hour)),
+					jwt.String("tenant", tenant),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				req.Header.Set("Authorization", "Bearer "+string(token))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			return rec
+		}
+
+		BeforeEach(func() {
+			policy := jwt.MustCompilePolicy(`claims.tenant == request.tenant`, "tenant")
+			attributes := func(r *http.Request) map[string]interface{} {
+				return map[string]interface{}{"tenant": r.URL.Query().Get("tenant")}
+			}
+
+			ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			middleware := &jwt.Middleware{Verifier: verifier, Realm: "api", Optional: true}
+
+			handler = middleware.Handler(jwt.RequirePolicy(policy, attributes)(ok))
+		})
+
+		It("should allow requests that pass the policy", func() {
+			Expect(serve("/?tenant=acme", "acme").Code).To(Equal(http.StatusOK))
+		})
+
+		It("should forbid requests that do not pass the policy", func() {
+			rec := serve("/?tenant=other", "acme")
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix(`Bearer realm="api", error="insufficient_scope"`))
+		})
+
+		It("should challenge unauthenticated requests", func() {
+			rec := serve("/?tenant=acme", "")
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="api"`))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenReplayed is the error returned when a one-time token has already been used.
+var ErrTokenReplayed = errors.New("token has already been used")
+
+// ErrTokenIDRequired is the error returned when a token has no jti claim and the verifier requires one.
+var ErrTokenIDRequired = errors.New("token ID required")
+
+// ReplayStore records the IDs (the jti claim) of used one-time tokens until they expire.
+// Implementations must be safe for concurrent use, and Use must check and record atomically.
+type ReplayStore interface {
+	// Use records the token ID until the expiry time (a zero expiry time never expires), it
+	// returns false if the token ID had already been recorded.
+	Use(ctx context.Context, id string, expires time.Time) (bool, error)
+}
+
+// MemoryReplayStore implements the `ReplayStore` interface and holds used token IDs in memory,
+// the zero value is an empty store.
+type MemoryReplayStore struct {
+	// Clock is used to expire entries, it defaults to the wall clock.
+	Clock Clock
+
+	mu   sync.Mutex
+	used expirySet
+}
+
+// NewMemoryReplayStore returns an empty `MemoryReplayStore`.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{
+		used: expirySet{},
+	}
+}
+
+// Use records the token ID until the expiry time, it returns false if the token ID had already
+// been recorded and has not expired. Expired entries are removed.
+func (s *MemoryReplayStore) Use(ctx context.Context, id string, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.used == nil {
+		s.used = expirySet{}
+	}
+
+	now := clockOrSystem(s.Clock).Now()
+	if s.used.contains(id, now) {
+		return false, nil
+	}
+
+	s.used.prune(now)
+	s.used.add(id, expires)
+
+	return true, nil
+}
@@ -0,0 +1,137 @@
+package jwt_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	pjwt "github.com/pascaldekloe/jwt"
+)
+
+var _ = Describe("JWT Replay Protection", func() {
+	var clock *jwt.FakeClock
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var store *jwt.MemoryReplayStore
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		signer = createSigner()
+
+		store = jwt.NewMemoryReplayStore()
+		store.Clock = clock
+
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+		verifier.Replay = store
+	})
+
+	It("should reject a second use of a token", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+
+		result, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenReplayed))
+		Expect(result.Subject).To(BeEmpty())
+	})
+
+	It("should not record a token that failed verification", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now().Add(time.Minute), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenNotYetValid))
+
+		clock.Advance(time.Minute)
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should accept a token once across concurrent verifications", func() {
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		accepted := 0
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if _, err := verifier.Verify(token); err == nil {
+					mu.Lock()
+					accepted++
+					mu.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+		Expect(accepted).To(Equal(1))
+	})
+
+	It("should forget token IDs once they expire", func() {
+		ctx := context.Background()
+		Expect(store.Use(ctx, "id", clock.Now().Add(time.Minute))).To(BeTrue())
+		Expect(store.Use(ctx, "id", clock.Now().Add(time.Minute))).To(BeFalse())
+
+		clock.Advance(time.Minute)
+		Expect(store.Use(ctx, "id", clock.Now().Add(time.Minute))).To(BeTrue())
+	})
+
+	It("should be usable as a zero value", func() {
+		ctx := context.Background()
+		store := &jwt.MemoryReplayStore{Clock: clock}
+
+		Expect(store.Use(ctx, "id", clock.Now().Add(time.Minute))).To(BeTrue())
+		Expect(store.Use(ctx, "id", clock.Now().Add(time.Minute))).To(BeFalse())
+	})
+
+	It("should reject a token without an ID", func() {
+		privateKey, err := jwt.ParsePKCS1PrivateKeyFromFileAFS(createAfs(), "key.pem")
+		Expect(err).NotTo(HaveOccurred())
+
+		claims := &pjwt.Claims{}
+		claims.Audiences = []string{"audience"}
+		claims.Expires = pjwt.NewNumericTime(clock.Now().Add(time.Hour))
+		token, err := claims.RSASign(jwt.RS256, privateKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenIDRequired))
+	})
+
+	It("should reject a token without an expiry", func() {
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenExpiryRequired))
+	})
+
+	It("should reject a second use of a token within the leeway after it expires", func() {
+		verifier.Leeway = 5 * time.Minute
+
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Minute))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+
+		clock.Advance(3 * time.Minute)
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenReplayed))
+	})
+})
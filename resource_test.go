@@ -25,6 +25,10 @@ func createSigner() jwt.Signer {
 }
 
 func createVerifier() jwt.Verifier {
+	return createRSAVerifier()
+}
+
+func createRSAVerifier() *jwt.RSAVerifier {
 	publicKey, err := jwt.ParsePKCS1PublicKeyFromFileAFS(createAfs(), "cert.pem")
 	Expect(err).NotTo(HaveOccurred())
 
@@ -0,0 +1,257 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ErrTokenRevoked is the error returned when a token has been revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationStore records revoked token IDs (the jti claim) until the token would have expired.
+type RevocationStore interface {
+	// Revoke marks the token ID as revoked until the expiry time, a zero expiry time never expires.
+	Revoke(ctx context.Context, id string, expires time.Time) error
+	// IsRevoked returns true if the token ID has been revoked.
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// RevokeToken revokes the token of a verified result in the supplied store until it expires plus
+// the leeway, which must be at least the largest `RSAVerifier.Leeway` of the verifiers using the
+// store, as they accept the token until then.
+func RevokeToken(ctx context.Context, store RevocationStore, result VerifyResult, leeway time.Duration) error {
+	if result.ID == "" {
+		return ErrClaimNotFound
+	}
+
+	expires := result.Expires
+	if !expires.IsZero() {
+		expires = expires.Add(leeway)
+	}
+
+	return store.Revoke(ctx, result.ID, expires)
+}
+
+// expirySet is a set of IDs that are removed once their expiry time passes.
+type expirySet map[string]time.Time
+
+// add adds the ID to the set until the expiry time, a zero expiry time never expires.
+func (s expirySet) add(id string, expires time.Time) {
+	if current, ok := s[id]; ok && (current.IsZero() || (!expires.IsZero() && current.After(expires))) {
+		return
+	}
+
+	s[id] = expires
+}
+
+// contains returns true if the ID is in the set and has not expired at the supplied time.
+func (s expirySet) contains(id string, now time.Time) bool {
+	expires, ok := s[id]
+
+	return ok && (expires.IsZero() || expires.After(now))
+}
+
+// prune removes all IDs that have expired at the supplied time.
+func (s expirySet) prune(now time.Time) {
+	for id, expires := range s {
+		if !expires.IsZero() && !expires.After(now) {
+			delete(s, id)
+		}
+	}
+}
+
+// MemoryRevocationStore implements the `RevocationStore` interface and holds revoked token IDs in memory,
+// the zero value is an empty store.
+type MemoryRevocationStore struct {
+	// Clock is used to expire entries, it defaults to the wall clock.
+	Clock Clock
+
+	mu      sync.RWMutex
+	revoked expirySet
+}
+
+// NewMemoryRevocationStore returns an empty `MemoryRevocationStore`.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked: expirySet{},
+	}
+}
+
+// Revoke marks the token ID as revoked until the expiry time, expired entries are removed.
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, id string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revoked == nil {
+		s.revoked = expirySet{}
+	}
+
+	s.revoked.prune(clockOrSystem(s.Clock).Now())
+	s.revoked.add(id, expires)
+
+	return nil
+}
+
+// IsRevoked returns true if the token ID has been revoked and has not expired.
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revoked.contains(id, clockOrSystem(s.Clock).Now()), nil
+}
+
+// FileRevocationStore implements the `RevocationStore` interface and persists revoked token IDs
+// to a JSON file. The file is read when the store is created and rewritten on every revocation.
+type FileRevocationStore struct {
+	// Clock is used to expire entries, it defaults to the wall clock.
+	Clock Clock
+
+	afs      afero.Fs
+	filename string
+	mu       sync.RWMutex
+	revoked  expirySet
+}
+
+// NewFileRevocationStore returns a `FileRevocationStore` persisted to the supplied file.
+func NewFileRevocationStore(filename string) (*FileRevocationStore, error) {
+	return NewFileRevocationStoreAFS(afero.NewOsFs(), filename)
+}
+
+// NewFileRevocationStoreAFS returns a `FileRevocationStore` persisted to the supplied file with a supplied `afero.Fs`.
+func NewFileRevocationStoreAFS(afs afero.Fs, filename string) (*FileRevocationStore, error) {
+	s := &FileRevocationStore{
+		afs:      afs,
+		filename: filename,
+		revoked:  expirySet{},
+	}
+
+	data, err := afero.ReadFile(afs, filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.revoked); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Revoke marks the token ID as revoked until the expiry time and writes the store to disk,
+// expired entries are removed.
+func (s *FileRevocationStore) Revoke(ctx context.Context, id string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoked.prune(clockOrSystem(s.Clock).Now())
+	s.revoked.add(id, expires)
+
+	data, err := json.Marshal(s.revoked)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.filename + ".tmp"
+	if err := afero.WriteFile(s.afs, tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return s.afs.Rename(tmp, s.filename)
+}
+
+// IsRevoked returns true if the token ID has been revoked and has not expired.
+func (s *FileRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revoked.contains(id, clockOrSystem(s.Clock).Now()), nil
+}
+
+// SessionID is the claim for the session ID of a token, as used by OpenID Connect.
+const SessionID string = "sid"
+
+// IssuedBeforeStore records, per subject (the sub claim) and session (the sid claim), the time
+// before which issued tokens are revoked (eg. when a user resets their password).
+type IssuedBeforeStore interface {
+	// RevokedBefore returns the time before which tokens issued for the subject or session are
+	// revoked, or the zero time if they are not.
+	RevokedBefore(ctx context.Context, subject, session string) (time.Time, error)
+}
+
+// MemoryIssuedBeforeStore implements the `IssuedBeforeStore` interface and holds revocations in memory,
+// the zero value is an empty store.
+type MemoryIssuedBeforeStore struct {
+	mu       sync.RWMutex
+	subjects map[string]time.Time
+	sessions map[string]time.Time
+}
+
+// NewMemoryIssuedBeforeStore returns an empty `MemoryIssuedBeforeStore`.
+func NewMemoryIssuedBeforeStore() *MemoryIssuedBeforeStore {
+	return &MemoryIssuedBeforeStore{
+		subjects: map[string]time.Time{},
+		sessions: map[string]time.Time{},
+	}
+}
+
+// RevokeSubject revokes all tokens for the subject issued before the supplied time.
+func (s *MemoryIssuedBeforeStore) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subjects == nil {
+		s.subjects = map[string]time.Time{}
+	}
+
+	if before.After(s.subjects[subject]) {
+		s.subjects[subject] = before
+	}
+
+	return nil
+}
+
+// RevokeSession revokes all tokens for the session issued before the supplied time.
+func (s *MemoryIssuedBeforeStore) RevokeSession(ctx context.Context, session string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions == nil {
+		s.sessions = map[string]time.Time{}
+	}
+
+	if before.After(s.sessions[session]) {
+		s.sessions[session] = before
+	}
+
+	return nil
+}
+
+// RevokedBefore returns the later of the subject and session revocation times.
+func (s *MemoryIssuedBeforeStore) RevokedBefore(ctx context.Context, subject, session string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	before := time.Time{}
+
+	if subject != "" {
+		before = s.subjects[subject]
+	}
+
+	if session != "" && s.sessions[session].After(before) {
+		before = s.sessions[session]
+	}
+
+	return before, nil
+}
@@ -0,0 +1,280 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	pjwt "github.com/pascaldekloe/jwt"
+	"github.com/spf13/afero"
+)
+
+type failingRevocationStore struct{}
+
+func (failingRevocationStore) Revoke(ctx context.Context, id string, expires time.Time) error {
+	return errors.New("store unavailable")
+}
+
+func (failingRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+var _ = Describe("JWT Revocation", func() {
+	var clock *jwt.FakeClock
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		signer = createSigner()
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+	})
+
+	Context("with a memory store", func() {
+		var store *jwt.MemoryRevocationStore
+
+		BeforeEach(func() {
+			store = jwt.NewMemoryRevocationStore()
+			store.Clock = clock
+			verifier.Revocations = store
+		})
+
+		It("should reject a revoked token", func() {
+			token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := verifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jwt.RevokeToken(context.Background(), store, result, verifier.Leeway)).To(Succeed())
+
+			result, err = verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenRevoked))
+			Expect(result.Subject).To(BeEmpty())
+
+			var verr *jwt.ValidationError
+			Expect(errors.As(err, &verr)).To(BeTrue())
+			Expect(verr.Flags).To(Equal(jwt.ValidationErrorRevoked))
+		})
+
+		It("should reject a revoked token within the leeway after it expires", func() {
+			verifier.Leeway = 5 * time.Minute
+
+			token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := verifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jwt.RevokeToken(context.Background(), store, result, verifier.Leeway)).To(Succeed())
+
+			clock.Advance(3 * time.Minute)
+
+			_, err = verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenRevoked))
+		})
+
+		It("should expire entries once the token expires", func() {
+			ctx := context.Background()
+			Expect(store.Revoke(ctx, "expiring", clock.Now().Add(time.Minute))).To(Succeed())
+			Expect(store.Revoke(ctx, "forever", time.Time{})).To(Succeed())
+			Expect(store.IsRevoked(ctx, "expiring")).To(BeTrue())
+
+			clock.Advance(time.Minute)
+			Expect(store.IsRevoked(ctx, "expiring")).To(BeFalse())
+			Expect(store.IsRevoked(ctx, "forever")).To(BeTrue())
+		})
+
+		It("should keep the longest expiry for repeated revocations", func() {
+			ctx := context.Background()
+			Expect(store.Revoke(ctx, "id", clock.Now().Add(time.Hour))).To(Succeed())
+			Expect(store.Revoke(ctx, "id", clock.Now().Add(time.Minute))).To(Succeed())
+
+			clock.Advance(30 * time.Minute)
+			Expect(store.IsRevoked(ctx, "id")).To(BeTrue())
+		})
+
+		It("should be usable as a zero value", func() {
+			ctx := context.Background()
+			store := &jwt.MemoryRevocationStore{Clock: clock}
+
+			Expect(store.IsRevoked(ctx, "id")).To(BeFalse())
+			Expect(store.Revoke(ctx, "id", clock.Now().Add(time.Minute))).To(Succeed())
+			Expect(store.IsRevoked(ctx, "id")).To(BeTrue())
+		})
+	})
+
+	Context("with a file store", func() {
+		var afs afero.Fs
+
+		BeforeEach(func() {
+			afs = afero.NewMemMapFs()
+		})
+
+		It("should persist revocations", func() {
+			ctx := context.Background()
+
+			store, err := jwt.NewFileRevocationStoreAFS(afs, "revoked.json")
+			Expect(err).NotTo(HaveOccurred())
+			store.Clock = clock
+
+			Expect(store.Revoke(ctx, "id", clock.Now().Add(time.Hour))).To(Succeed())
+			Expect(store.IsRevoked(ctx, "id")).To(BeTrue())
+
+			reloaded, err := jwt.NewFileRevocationStoreAFS(afs, "revoked.json")
+			Expect(err).NotTo(HaveOccurred())
+			reloaded.Clock = clock
+			Expect(reloaded.IsRevoked(ctx, "id")).To(BeTrue())
+			Expect(reloaded.IsRevoked(ctx, "other")).To(BeFalse())
+
+			clock.Advance(time.Hour)
+			Expect(reloaded.IsRevoked(ctx, "id")).To(BeFalse())
+		})
+
+		It("should reject a revoked token", func() {
+			store, err := jwt.NewFileRevocationStoreAFS(afs, "revoked.json")
+			Expect(err).NotTo(HaveOccurred())
+			verifier.Revocations = store
+
+			token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String(jwt.ID, "ponies"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.Revoke(context.Background(), "ponies", time.Time{})).To(Succeed())
+
+			_, err = verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenRevoked))
+		})
+
+		It("should fail to load an invalid file", func() {
+			Expect(afero.WriteFile(afs, "revoked.json", []byte("garbage"), 0600)).To(Succeed())
+
+			_, err := jwt.NewFileRevocationStoreAFS(afs, "revoked.json")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should fail closed when the store fails", func() {
+		verifier.Revocations = failingRevocationStore{}
+
+		token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+
+		var verr *jwt.ValidationError
+		Expect(errors.As(err, &verr)).To(BeTrue())
+		Expect(verr.Flags).To(Equal(jwt.ValidationErrorLookup))
+	})
+
+	It("should reject a token without an ID", func() {
+		verifier.Revocations = jwt.NewMemoryRevocationStore()
+
+		privateKey, err := jwt.ParsePKCS1PrivateKeyFromFileAFS(createAfs(), "key.pem")
+		Expect(err).NotTo(HaveOccurred())
+
+		claims := &pjwt.Claims{}
+		claims.Audiences = []string{"audience"}
+		token, err := claims.RSASign(jwt.RS256, privateKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenIDRequired))
+	})
+
+	Context("with an issued before store", func() {
+		var store *jwt.MemoryIssuedBeforeStore
+
+		BeforeEach(func() {
+			store = jwt.NewMemoryIssuedBeforeStore()
+			verifier.IssuedBefore = store
+		})
+
+		It("should reject tokens for a subject issued before the revocation", func() {
+			ctx := context.Background()
+
+			before, err := signer.SignClaims(
+				jwt.String(jwt.Subject, "subject"),
+				jwt.String(jwt.Audience, "audience"),
+				jwt.Time(jwt.Issued, clock.Now().Add(-1*time.Minute)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			after, err := signer.SignClaims(
+				jwt.String(jwt.Subject, "subject"),
+				jwt.String(jwt.Audience, "audience"),
+				jwt.Time(jwt.Issued, clock.Now()),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			other, err := signer.SignClaims(
+				jwt.String(jwt.Subject, "other"),
+				jwt.String(jwt.Audience, "audience"),
+				jwt.Time(jwt.Issued, clock.Now().Add(-1*time.Minute)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.RevokeSubject(ctx, "subject", clock.Now())).To(Succeed())
+
+			_, err = verifier.Verify(before)
+			Expect(err).To(MatchError(jwt.ErrTokenRevoked))
+
+			_, err = verifier.Verify(after)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = verifier.Verify(other)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject tokens for a session issued before the revocation", func() {
+			ctx := context.Background()
+
+			token, err := signer.SignClaims(
+				jwt.String(jwt.Subject, "subject"),
+				jwt.String(jwt.Audience, "audience"),
+				jwt.String(jwt.SessionID, "session"),
+				jwt.Time(jwt.Issued, clock.Now().Add(-1*time.Minute)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.RevokeSession(ctx, "other-session", clock.Now())).To(Succeed())
+
+			_, err = verifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.RevokeSession(ctx, "session", clock.Now())).To(Succeed())
+
+			_, err = verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenRevoked))
+		})
+
+		It("should not move a revocation time backwards", func() {
+			ctx := context.Background()
+
+			Expect(store.RevokeSubject(ctx, "subject", clock.Now())).To(Succeed())
+			Expect(store.RevokeSubject(ctx, "subject", clock.Now().Add(-1*time.Hour))).To(Succeed())
+			Expect(store.RevokedBefore(ctx, "subject", "")).To(Equal(clock.Now()))
+		})
+
+		It("should be usable as a zero value", func() {
+			ctx := context.Background()
+			store := &jwt.MemoryIssuedBeforeStore{}
+
+			Expect(store.RevokedBefore(ctx, "subject", "session")).To(BeZero())
+			Expect(store.RevokeSubject(ctx, "subject", clock.Now())).To(Succeed())
+			Expect(store.RevokeSession(ctx, "session", clock.Now().Add(time.Minute))).To(Succeed())
+			Expect(store.RevokedBefore(ctx, "subject", "session")).To(Equal(clock.Now().Add(time.Minute)))
+		})
+
+		It("should require an issued time", func() {
+			token, err := jwt.Sign(signer, "subject", "audience", false, clock.Now(), clock.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenIssuedRequired))
+		})
+	})
+})
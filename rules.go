@@ -0,0 +1,261 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ErrTokenClaimInvalid is the error returned when a token claim fails a verification `Rule`.
+var ErrTokenClaimInvalid = errors.New("invalid token claim")
+
+// Rule validates the claims of a token, verifiers run rules after the signature,
+// audience and time checks have passed.
+type Rule interface {
+	// Validate returns an error if the result does not satisfy the rule.
+	Validate(result VerifyResult) error
+}
+
+// RuleFunc is an adapter to allow the use of ordinary functions as a `Rule`.
+type RuleFunc func(result VerifyResult) error
+
+// Validate calls f(result).
+func (f RuleFunc) Validate(result VerifyResult) error {
+	return f(result)
+}
+
+// ContextRule is a `Rule` that also accepts the context of the verification, verifiers call
+// ValidateContext in preference to Validate.
+type ContextRule interface {
+	Rule
+	// ValidateContext returns an error if the result does not satisfy the rule.
+	ValidateContext(ctx context.Context, result VerifyResult) error
+}
+
+// ContextRuleFunc is an adapter to allow the use of ordinary functions as a `ContextRule`.
+type ContextRuleFunc func(ctx context.Context, result VerifyResult) error
+
+// Validate calls f(context.Background(), result).
+func (f ContextRuleFunc) Validate(result VerifyResult) error {
+	return f(context.Background(), result)
+}
+
+// ValidateContext calls f(ctx, result).
+func (f ContextRuleFunc) ValidateContext(ctx context.Context, result VerifyResult) error {
+	return f(ctx, result)
+}
+
+// validateRule runs the rule with the context if it is a `ContextRule`.
+func validateRule(ctx context.Context, rule Rule, result VerifyResult) error {
+	if cr, ok := rule.(ContextRule); ok {
+		return cr.ValidateContext(ctx, result)
+	}
+
+	return rule.Validate(result)
+}
+
+// AllOf returns a `Rule` that requires every supplied rule to pass.
+func AllOf(rules ...Rule) Rule {
+	return ContextRuleFunc(func(ctx context.Context, result VerifyResult) error {
+		for _, rule := range rules {
+			if err := validateRule(ctx, rule, result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AnyOf returns a `Rule` that requires at least one of the supplied rules to pass.
+func AnyOf(rules ...Rule) Rule {
+	return ContextRuleFunc(func(ctx context.Context, result VerifyResult) error {
+		reasons := make([]string, 0, len(rules))
+
+		for _, rule := range rules {
+			err := validateRule(ctx, rule, result)
+			if err == nil {
+				return nil
+			}
+
+			reasons = append(reasons, err.Error())
+		}
+
+		return fmt.Errorf("%w: no rule passed (%s)", ErrTokenClaimInvalid, strings.Join(reasons, "; "))
+	})
+}
+
+// Present returns a `Rule` that requires the claim to be present.
+func Present(key string) Rule {
+	return RuleFunc(func(result VerifyResult) error {
+		_, err := ruleClaims(result, key)
+
+		return err
+	})
+}
+
+// Equals returns a `Rule` that requires the claim to equal the supplied value.
+func Equals(key string, value interface{}) Rule {
+	want := ruleValue(Any(key, value))
+
+	return RuleFunc(func(result VerifyResult) error {
+		claims, err := ruleClaims(result, key)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range claims {
+			if !reflect.DeepEqual(ruleValue(c), want) {
+				return fmt.Errorf("%w: %s must equal %v", ErrTokenClaimInvalid, key, value)
+			}
+		}
+
+		return nil
+	})
+}
+
+// OneOf returns a `Rule` that requires the claim to equal one of the supplied values.
+func OneOf(key string, values ...interface{}) Rule {
+	want := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		want = append(want, ruleValue(Any(key, v)))
+	}
+
+	return RuleFunc(func(result VerifyResult) error {
+		claims, err := ruleClaims(result, key)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range claims {
+			if !containsValue(want, ruleValue(c)) {
+				return fmt.Errorf("%w: %s must be one of %v", ErrTokenClaimInvalid, key, values)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Contains returns a `Rule` that requires the claim to contain the supplied value. Array claims
+// (and multiple claims with the same key, eg. audiences) must have an element equal to the value,
+// string claims are treated as a space-delimited list (eg. an OAuth2 scope).
+func Contains(key string, value interface{}) Rule {
+	want := ruleValue(Any(key, value))
+
+	return RuleFunc(func(result VerifyResult) error {
+		claims, err := ruleClaims(result, key)
+		if err != nil {
+			return err
+		}
+
+		elements := []interface{}{}
+
+		for _, c := range claims {
+			switch v := ruleValue(c).(type) {
+			case string:
+				for _, s := range strings.Fields(v) {
+					elements = append(elements, s)
+				}
+			case []interface{}:
+				elements = append(elements, v...)
+			default:
+				elements = append(elements, v)
+			}
+		}
+
+		if !containsValue(elements, want) {
+			return fmt.Errorf("%w: %s must contain %v", ErrTokenClaimInvalid, key, value)
+		}
+
+		return nil
+	})
+}
+
+// Matches returns a `Rule` that requires the claim to be a string matching the supplied regular expression.
+func Matches(key string, re *regexp.Regexp) Rule {
+	return RuleFunc(func(result VerifyResult) error {
+		claims, err := ruleClaims(result, key)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range claims {
+			s, err := c.AsString()
+			if err != nil || !re.MatchString(s) {
+				return fmt.Errorf("%w: %s must match %s", ErrTokenClaimInvalid, key, re)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Range returns a `Rule` that requires the claim to be a number between low and high (inclusive).
+// Time claims are compared as seconds since the epoch, durations as seconds.
+func Range(key string, low, high float64) Rule {
+	return RuleFunc(func(result VerifyResult) error {
+		claims, err := ruleClaims(result, key)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range claims {
+			f, ok := ruleValue(c).(float64)
+			if !ok || f < low || f > high {
+				return fmt.Errorf("%w: %s must be between %v and %v", ErrTokenClaimInvalid, key, low, high)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ruleClaims returns the claims for a key, or an error if the claim is not present.
+func ruleClaims(result VerifyResult, key string) ([]Claim, error) {
+	claims, ok := result.Claims[key]
+	if !ok || len(claims) == 0 {
+		return nil, fmt.Errorf("%w: %s is not present", ErrTokenClaimInvalid, key)
+	}
+
+	return claims, nil
+}
+
+// ruleValue normalizes a `Claim` value for comparison, all numbers (including times
+// and durations) are returned as float64.
+func ruleValue(c Claim) interface{} {
+	if s, err := c.AsString(); err == nil {
+		return s
+	}
+
+	if f, err := c.AsFloat64(); err == nil {
+		return f
+	}
+
+	switch c.Type {
+	case TimeType:
+		t, _ := c.AsTime()
+
+		return float64(t.UnixNano()) / 1e9
+	case DurationType:
+		d, _ := c.AsDuration()
+
+		return d.Seconds()
+	default:
+		return claimValue(c)
+	}
+}
+
+// containsValue returns true if the slice contains an element equal to the value.
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
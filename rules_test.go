@@ -0,0 +1,95 @@
+package jwt_test
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Verifier Rules", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+	var token []byte
+
+	BeforeEach(func() {
+		var err error
+
+		signer = createSigner()
+		verifier = createRSAVerifier()
+
+		token, err = signer.SignClaims(
+			jwt.String(jwt.Subject, "subject"),
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time(jwt.Expires, time.Now().Add(time.Hour)),
+			jwt.String("tenant", "acme"),
+			jwt.String("scope", "orders:read orders:write"),
+			jwt.Reflect("roles", []string{"admin", "user"}),
+			jwt.Int("level", 5),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("should succeed",
+		func(rules ...jwt.Rule) {
+			verifier.Rules = rules
+
+			result, err := verifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Subject).To(Equal("subject"))
+		},
+		Entry("present", jwt.Present(jwt.Subject), jwt.Present(jwt.ID)),
+		Entry("equals", jwt.Equals("tenant", "acme"), jwt.Equals("level", 5)),
+		Entry("one of", jwt.OneOf("tenant", "acme", "globex")),
+		Entry("contains (space-delimited)", jwt.Contains("scope", "orders:write")),
+		Entry("contains (array)", jwt.Contains("roles", "admin")),
+		Entry("contains (audiences)", jwt.Contains(jwt.Audience, "audience")),
+		Entry("matches", jwt.Matches(jwt.Subject, regexp.MustCompile(`^sub`))),
+		Entry("range", jwt.Range("level", 1, 5)),
+		Entry("range (time)", jwt.Range(jwt.Expires, float64(time.Now().Unix()), float64(time.Now().Add(2*time.Hour).Unix()))),
+		Entry("all of", jwt.AllOf(jwt.Present(jwt.Subject), jwt.Equals("tenant", "acme"))),
+		Entry("any of", jwt.AnyOf(jwt.Equals("tenant", "globex"), jwt.Contains("roles", "admin"))),
+		Entry("custom", jwt.RuleFunc(func(result jwt.VerifyResult) error { return nil })),
+	)
+
+	DescribeTable("should fail",
+		func(rules ...jwt.Rule) {
+			verifier.Rules = rules
+
+			result, err := verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenClaimInvalid))
+			Expect(result.Subject).To(BeEmpty())
+		},
+		Entry("present", jwt.Present("sid")),
+		Entry("equals", jwt.Equals("tenant", "globex")),
+		Entry("equals (wrong type)", jwt.Equals("level", "5")),
+		Entry("one of", jwt.OneOf("tenant", "globex", "initech")),
+		Entry("contains (space-delimited)", jwt.Contains("scope", "orders:delete")),
+		Entry("contains (array)", jwt.Contains("roles", "owner")),
+		Entry("matches", jwt.Matches("tenant", regexp.MustCompile(`^globex$`))),
+		Entry("range", jwt.Range("level", 6, 10)),
+		Entry("range (not a number)", jwt.Range("tenant", 0, 10)),
+		Entry("all of", jwt.AllOf(jwt.Present(jwt.Subject), jwt.Equals("tenant", "globex"))),
+		Entry("any of", jwt.AnyOf(jwt.Equals("tenant", "globex"), jwt.Contains("roles", "owner"))),
+	)
+
+	It("should return the error from a custom rule", func() {
+		errCustom := errors.New("custom rule failed")
+		verifier.Rules = []jwt.Rule{
+			jwt.RuleFunc(func(result jwt.VerifyResult) error {
+				if result.Subject == "subject" {
+					return errCustom
+				}
+
+				return nil
+			}),
+		}
+
+		_, err := verifier.Verify(token)
+		Expect(err).To(MatchError(errCustom))
+	})
+})
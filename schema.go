@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/pascaldekloe/jwt"
+)
+
+// ErrTokenInvalidClaimType is the error returned when a claim does not match the type declared in a `ClaimSchema`.
+var ErrTokenInvalidClaimType = errors.New("invalid token claim type")
+
+// ErrSchemaTypeUnsupported is the error a `ClaimSchema` panics with when a claim is declared with a
+// `ClaimType` that can not be decoded from a token.
+var ErrSchemaTypeUnsupported = errors.New("unsupported claim schema type")
+
+// schemaClaim is the declared type of a claim in a `ClaimSchema`.
+type schemaClaim struct {
+	claimType ClaimType
+	prototype reflect.Type
+}
+
+// ClaimSchema declares the expected type of unregistered claims, so a verifier can decode them
+// into the declared type instead of guessing from the JSON value (eg. a `TimeType` claim would
+// otherwise be read as a `Float64Type`). The zero value is an empty schema.
+type ClaimSchema struct {
+	claims map[string]schemaClaim
+}
+
+// NewClaimSchema returns an empty `ClaimSchema`.
+func NewClaimSchema() *ClaimSchema {
+	return &ClaimSchema{
+		claims: map[string]schemaClaim{},
+	}
+}
+
+// Declare sets the expected `ClaimType` of the claim with the supplied key. It panics with
+// `ErrSchemaTypeUnsupported` if the type can not be decoded from a token (eg. `UnknownType`),
+// so schema mistakes fail at startup rather than rejecting every token.
+func (s *ClaimSchema) Declare(key string, claimType ClaimType) *ClaimSchema {
+	if !schemaTypeSupported(claimType) {
+		panic(fmt.Errorf("%w: %d (%s)", ErrSchemaTypeUnsupported, claimType, key))
+	}
+
+	s.declare(key, schemaClaim{claimType: claimType})
+
+	return s
+}
+
+// DeclareReflect sets the expected type of the claim with the supplied key to the type of the
+// prototype, the claim is decoded into a new value of that type and returned as a `ReflectType`.
+func (s *ClaimSchema) DeclareReflect(key string, prototype interface{}) *ClaimSchema {
+	s.declare(key, schemaClaim{claimType: ReflectType, prototype: reflect.TypeOf(prototype)})
+
+	return s
+}
+
+// declare records the declared type of a claim, the zero `ClaimSchema` is empty.
+func (s *ClaimSchema) declare(key string, sc schemaClaim) {
+	if s.claims == nil {
+		s.claims = map[string]schemaClaim{}
+	}
+
+	s.claims[key] = sc
+}
+
+// schemaTypeSupported returns true if claims of the type can be decoded from a token.
+func schemaTypeSupported(claimType ClaimType) bool {
+	switch claimType {
+	case StringType, StringerType, ErrorType, BoolType,
+		Float64Type, Float32Type,
+		Int64Type, Int32Type, Int16Type, Int8Type,
+		Uint64Type, Uint32Type, Uint16Type, Uint8Type,
+		TimeType, DurationType, BinaryType, ReflectType:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decode returns the `Claim` for a decoded JSON value of an unregistered claim. Claims that are not
+// declared (or a nil schema) fall back to `Any`, declared claims that do not match the declared
+// type return `ErrTokenInvalidClaimType`.
+func (s *ClaimSchema) Decode(key string, value interface{}) (Claim, error) {
+	if s == nil {
+		return Any(key, value), nil
+	}
+
+	sc, ok := s.claims[key]
+	if !ok {
+		return Any(key, value), nil
+	}
+
+	c, ok := sc.decode(key, value)
+	if !ok {
+		return Claim{}, fmt.Errorf("%w: %s", ErrTokenInvalidClaimType, key)
+	}
+
+	return c, nil
+}
+
+//nolint:gocyclo
+func (sc schemaClaim) decode(key string, value interface{}) (Claim, bool) {
+	switch sc.claimType {
+	case StringType, StringerType, ErrorType:
+		if v, ok := value.(string); ok {
+			return String(key, v), true
+		}
+	case BoolType:
+		if v, ok := value.(bool); ok {
+			return Bool(key, v), true
+		}
+	case Float64Type, Float32Type:
+		if v, ok := value.(float64); ok {
+			return Float(key, v), true
+		}
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) {
+			return Int(key, int64(v)), true
+		}
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && v >= 0 {
+			return Uint(key, uint64(v)), true
+		}
+	case TimeType:
+		if v, ok := value.(float64); ok {
+			t := jwt.NumericTime(v)
+
+			return Time(key, t.Time()), true
+		}
+	case DurationType:
+		if v, ok := value.(float64); ok {
+			return Duration(key, time.Duration(v*float64(time.Second))), true
+		}
+	case BinaryType:
+		if v, ok := value.(string); ok {
+			if b, err := base64.RawURLEncoding.DecodeString(v); err == nil {
+				return Binary(key, b), true
+			}
+		}
+	case ReflectType:
+		if sc.prototype == nil {
+			return Reflect(key, value), true
+		}
+
+		return sc.decodeReflect(key, value)
+	}
+
+	return Claim{}, false
+}
+
+// decodeReflect decodes a JSON value into a new value of the prototype type.
+func (sc schemaClaim) decodeReflect(key string, value interface{}) (Claim, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Claim{}, false
+	}
+
+	v := reflect.New(sc.prototype)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return Claim{}, false
+	}
+
+	return Reflect(key, v.Elem().Interface()), true
+}
@@ -0,0 +1,106 @@
+package jwt_test
+
+import (
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Claim Schema", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createRSAVerifier()
+	})
+
+	It("should decode declared claims into their declared type", func() {
+		login := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		address := testAddress{Street: "1 Main St", City: "Springfield"}
+
+		verifier.Schema = jwt.NewClaimSchema().
+			Declare("login", jwt.TimeType).
+			Declare("lifetime", jwt.DurationType).
+			Declare("blob", jwt.BinaryType).
+			DeclareReflect("address", testAddress{})
+
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Time("login", login),
+			jwt.Duration("lifetime", time.Minute),
+			jwt.Binary("blob", []byte("blob")),
+			jwt.Reflect("address", address),
+			jwt.String("undeclared", "foobar"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims["login"]).To(ConsistOf(jwt.Time("login", login)))
+		Expect(result.Claims["lifetime"]).To(ConsistOf(jwt.Duration("lifetime", time.Minute)))
+		Expect(result.Claims["blob"]).To(ConsistOf(jwt.Binary("blob", []byte("blob"))))
+		Expect(result.Claims["address"]).To(ConsistOf(jwt.Reflect("address", address)))
+		Expect(result.Claims["undeclared"]).To(ConsistOf(jwt.String("undeclared", "foobar")))
+	})
+
+	DescribeTable("should reject claims that do not match their declared type",
+		func(claimType jwt.ClaimType, claim jwt.Claim) {
+			verifier.Schema = jwt.NewClaimSchema().Declare("custom", claimType)
+
+			token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), claim)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := verifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenInvalidClaimType))
+			Expect(result.Claims).To(BeEmpty())
+		},
+		Entry("time as string", jwt.TimeType, jwt.String("custom", "yesterday")),
+		Entry("int as fraction", jwt.Int64Type, jwt.Float("custom", 1.5)),
+		Entry("uint as negative", jwt.Uint64Type, jwt.Int("custom", -1)),
+		Entry("string as bool", jwt.StringType, jwt.Bool("custom", true)),
+		Entry("binary as invalid base64", jwt.BinaryType, jwt.String("custom", "not base64!")),
+	)
+
+	It("should reject objects that do not match the declared prototype", func() {
+		verifier.Schema = jwt.NewClaimSchema().DeclareReflect("address", testAddress{})
+
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.Reflect("address", []string{"not", "an", "object"}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenInvalidClaimType))
+	})
+
+	It("should be usable as a zero value", func() {
+		verifier.Schema = (&jwt.ClaimSchema{}).Declare("custom", jwt.TimeType)
+
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String("custom", "yesterday"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrTokenInvalidClaimType))
+	})
+
+	DescribeTable("should panic when declaring a type that can not be decoded",
+		func(claimType jwt.ClaimType) {
+			defer func() {
+				err, _ := recover().(error)
+				Expect(err).To(MatchError(jwt.ErrSchemaTypeUnsupported))
+			}()
+
+			jwt.NewClaimSchema().Declare("custom", claimType)
+		},
+		Entry("unknown", jwt.UnknownType),
+		Entry("complex", jwt.Complex128Type),
+		Entry("namespace", jwt.NamespaceType),
+		Entry("skip", jwt.SkipType),
+		Entry("array marshaler", jwt.ArrayMarshalerType),
+	)
+})
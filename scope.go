@@ -0,0 +1,175 @@
+package jwt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Scope is the claim for the OAuth 2.0 scopes of a token, as a space-delimited string (RFC 8693).
+	Scope string = "scope"
+	// ScopeList is the claim for the scopes of a token used by some issuers, as a space-delimited
+	// string or an array.
+	ScopeList string = "scp"
+	// Roles is the claim for the roles of a token, as an array.
+	Roles string = "roles"
+)
+
+// ErrInsufficientScope is the error returned when a token does not have the required scopes,
+// it also matches `ErrTokenClaimInvalid`.
+var ErrInsufficientScope error = &insufficientScopeError{}
+
+// insufficientScopeError is a sentinel error that also matches `ErrTokenClaimInvalid`.
+type insufficientScopeError struct{}
+
+func (e *insufficientScopeError) Error() string {
+	return "insufficient scope"
+}
+
+func (e *insufficientScopeError) Is(target error) bool {
+	return target == ErrTokenClaimInvalid
+}
+
+// Permissions is a set of scopes or permissions granted to a token. Permissions are hierarchical,
+// with levels separated by a colon (eg. `orders:items:read`). A granted `*` level matches any
+// single level, and a trailing `*` matches any number of levels, so `orders:*` grants both
+// `orders:read` and `orders:items:read`, and `*` grants everything.
+type Permissions []string
+
+// ParsePermissions returns the permissions in the claims with the supplied keys of a verified
+// token, it defaults to the `Scope` and `ScopeList` claims. Claims may be space-delimited
+// strings or arrays of strings, other claims are ignored.
+func ParsePermissions(result VerifyResult, keys ...string) Permissions {
+	if len(keys) == 0 {
+		keys = []string{Scope, ScopeList}
+	}
+
+	p := Permissions{}
+
+	for _, key := range keys {
+		for _, c := range result.Claims[key] {
+			if s, err := c.AsString(); err == nil {
+				p = append(p, strings.Fields(s)...)
+
+				continue
+			}
+
+			if s, err := c.AsStrings(); err == nil {
+				p = append(p, s...)
+			}
+		}
+	}
+
+	return p
+}
+
+// Has returns true if any of the permissions grants the required permission.
+func (p Permissions) Has(required string) bool {
+	for _, granted := range p {
+		if matchPermission(granted, required) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAll returns true if every required permission is granted.
+func (p Permissions) HasAll(required ...string) bool {
+	for _, r := range required {
+		if !p.Has(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasAny returns true if at least one of the required permissions is granted.
+func (p Permissions) HasAny(required ...string) bool {
+	for _, r := range required {
+		if p.Has(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPermission returns true if the granted permission matches the required permission.
+func matchPermission(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+
+	g := strings.Split(granted, ":")
+	r := strings.Split(required, ":")
+
+	for i, level := range g {
+		if level == "*" && i == len(g)-1 {
+			return len(r) > i
+		}
+
+		if i >= len(r) || (level != "*" && level != r[i]) {
+			return false
+		}
+	}
+
+	return len(g) == len(r)
+}
+
+// HasScopes returns a `Rule` that requires every supplied scope to be granted by the `Scope` or
+// `ScopeList` claims, see `Permissions`.
+func HasScopes(scopes ...string) Rule {
+	return RuleFunc(func(result VerifyResult) error {
+		if !ParsePermissions(result).HasAll(scopes...) {
+			return fmt.Errorf("%w: requires %s", ErrInsufficientScope, strings.Join(scopes, " "))
+		}
+
+		return nil
+	})
+}
+
+// RequireScopes returns middleware that requires every supplied scope to be granted to the
+// token verified by a `Middleware` (see `ResultFromContext`), it responds with 401 to requests
+// without a verified token and 403 to tokens without the scopes.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return requirePermissions(scopes, Permissions.HasAll)
+}
+
+// RequireAnyScope is the same as RequireScopes, but only requires one of the supplied scopes.
+func RequireAnyScope(scopes ...string) func(http.Handler) http.Handler {
+	return requirePermissions(scopes, Permissions.HasAny)
+}
+
+// requirePermissions returns middleware that checks the permissions of the verified token.
+func requirePermissions(scopes []string, check func(Permissions, ...string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			realm := realmFromContext(r.Context())
+
+			result, ok := ResultFromContext(r.Context())
+			if !ok {
+				WriteBearerError(w, realm, ErrTokenNotFound)
+
+				return
+			}
+
+			if !check(ParsePermissions(result), scopes...) {
+				writeBearerChallenge(
+					w,
+					http.StatusForbidden,
+					realm,
+					"insufficient_scope",
+					"the access token does not have the required scope",
+					fmt.Sprintf("scope=%q", strings.Join(scopes, " ")),
+				)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
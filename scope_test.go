@@ -0,0 +1,158 @@
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT Scopes", func() {
+	var signer jwt.Signer
+	var verifier *jwt.RSAVerifier
+
+	verify := func(claims ...jwt.Claim) jwt.VerifyResult {
+		token, err := signer.SignClaims(append(claims, jwt.String(jwt.Audience, "audience"))...)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createRSAVerifier()
+	})
+
+	table.DescribeTable("permission matching",
+		func(granted, required string, expected bool) {
+			Expect(jwt.Permissions{granted}.Has(required)).To(Equal(expected))
+		},
+		table.Entry("exact", "orders:read", "orders:read", true),
+		table.Entry("different", "orders:read", "orders:write", false),
+		table.Entry("wildcard level", "orders:*", "orders:read", true),
+		table.Entry("wildcard levels", "orders:*", "orders:items:read", true),
+		table.Entry("wildcard requires a level", "orders:*", "orders", false),
+		table.Entry("inner wildcard", "orders:*:read", "orders:items:read", true),
+		table.Entry("inner wildcard mismatch", "orders:*:read", "orders:items:write", false),
+		table.Entry("global wildcard", "*", "users:delete", true),
+		table.Entry("parent does not grant child", "orders", "orders:read", false),
+		table.Entry("child does not grant parent", "orders:read", "orders", false),
+	)
+
+	It("should parse space-delimited and array scopes", func() {
+		result := verify(
+			jwt.String(jwt.Scope, "orders:read users:read"),
+			jwt.Any(jwt.ScopeList, []string{"orders:write"}),
+			jwt.Any(jwt.Roles, []string{"admin"}),
+		)
+
+		Expect(jwt.ParsePermissions(result)).To(ConsistOf("orders:read", "users:read", "orders:write"))
+		Expect(jwt.ParsePermissions(result, jwt.Roles)).To(ConsistOf("admin"))
+
+		p := jwt.ParsePermissions(result)
+		Expect(p.HasAll("orders:read", "orders:write")).To(BeTrue())
+		Expect(p.HasAll("orders:read", "users:write")).To(BeFalse())
+		Expect(p.HasAny("users:write", "users:read")).To(BeTrue())
+	})
+
+	It("should require scopes in a verifier rule", func() {
+		verifier.Rules = []jwt.Rule{jwt.HasScopes("orders:read")}
+
+		token, err := signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String(jwt.Scope, "users:read"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).To(MatchError(jwt.ErrInsufficientScope))
+		Expect(err).To(MatchError(jwt.ErrTokenClaimInvalid))
+
+		token, err = signer.SignClaims(jwt.String(jwt.Audience, "audience"), jwt.String(jwt.Scope, "orders:*"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("with middleware", func() {
+		var handler http.Handler
+
+		serve := func(scope string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			if scope != "" {
+				token, err := signer.SignClaims(
+					jwt.String(jwt.Audience, "audience"),
+					jwt.Time(jwt.Expires, time.Now().Add(time.Hour)),
+					jwt.String(jwt.Scope, scope),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				req.Header.Set("Authorization", "Bearer "+string(token))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			return rec
+		}
+
+		BeforeEach(func() {
+			ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			middleware := &jwt.Middleware{Verifier: verifier, Optional: true}
+
+			handler = middleware.Handler(jwt.RequireScopes("orders:read", "orders:write")(ok))
+		})
+
+		It("should allow tokens with the scopes", func() {
+			Expect(serve("orders:read orders:write").Code).To(Equal(http.StatusOK))
+			Expect(serve("orders:*").Code).To(Equal(http.StatusOK))
+		})
+
+		It("should forbid tokens without the scopes", func() {
+			rec := serve("orders:read")
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix(`Bearer error="insufficient_scope"`))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(HaveSuffix(`scope="orders:read orders:write"`))
+		})
+
+		It("should challenge unauthenticated requests", func() {
+			rec := serve("")
+			Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+		})
+
+		It("should use the realm of the middleware", func() {
+			handler = (&jwt.Middleware{Verifier: verifier, Realm: "api", Optional: true}).Handler(
+				jwt.RequireScopes("orders:read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			)
+
+			Expect(serve("users:read").Header().Get("WWW-Authenticate")).To(HavePrefix(`Bearer realm="api", error="insufficient_scope"`))
+			Expect(serve("").Header().Get("WWW-Authenticate")).To(Equal(`Bearer realm="api"`))
+		})
+
+		It("should forbid tokens failing a scope rule of the verifier", func() {
+			verifier.Rules = []jwt.Rule{jwt.HasScopes("orders:read")}
+			handler = (&jwt.Middleware{Verifier: verifier}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			rec := serve("users:read")
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+			Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix(`Bearer error="insufficient_scope"`))
+			Expect(serve("orders:read").Code).To(Equal(http.StatusOK))
+		})
+
+		It("should allow tokens with any of the scopes", func() {
+			handler = (&jwt.Middleware{Verifier: verifier}).Handler(
+				jwt.RequireAnyScope("orders:read", "orders:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			)
+
+			Expect(serve("orders:write").Code).To(Equal(http.StatusOK))
+			Expect(serve("users:read").Code).To(Equal(http.StatusForbidden))
+		})
+	})
+})
@@ -26,6 +26,8 @@ type RSASigner struct {
 	PrivateKey *rsa.PrivateKey
 	Issuer     string
 	Algorithm  string
+	// Clock, when set, is used to add an iat claim to tokens that do not supply one.
+	Clock Clock
 }
 
 // NewRSASignerFromFile returns an `RSASigner` initialized with the RSA Private Key supplied.
@@ -43,11 +45,16 @@ func NewRSASignerFromFile(filename string) (Signer, error) {
 
 // SignClaims takes a list of claims and produces a signed token.
 // Duplicate keys will we overridden in order of apearance!
-// The issuer defaults to r.Issuer.
+// The issuer defaults to r.Issuer, and the issued time to r.Clock if it is set.
 func (r *RSASigner) SignClaims(claims ...Claim) ([]byte, error) {
+	defaults := []Claim{String("iss", r.Issuer)}
+	if r.Clock != nil {
+		defaults = append(defaults, Time(Issued, r.Clock.Now()))
+	}
+
 	tokenClaims, err := ConstructClaimsFromSlice(
 		append(
-			[]Claim{String("iss", r.Issuer)},
+			defaults,
 			claims...,
 		)...,
 	)
@@ -79,7 +86,7 @@ func Sign(
 }
 
 // SignFingerprint takes a signer, subject, audience, fingerprint, online status, notBefore and expiry
-// and produces a signed token.
+// and produces a signed token. The fingerprint is usually the hash returned by `IssueFingerprint`.
 func SignFingerprint(
 	signer Signer,
 	subject,
@@ -0,0 +1,316 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pascaldekloe/jwt"
+)
+
+// ErrInvalidStruct is returned when a value supplied for encoding or decoding claims is not a struct.
+var ErrInvalidStruct = errors.New("invalid claims struct")
+
+// RegisteredClaims contains the IANA registered claims, it can be embedded in a user defined
+// claims struct to map the standard fields.
+type RegisteredClaims struct {
+	Issuer    string    `jwt:"iss,omitempty"`
+	Subject   string    `jwt:"sub,omitempty"`
+	Audiences []string  `jwt:"aud,omitempty"`
+	Expires   time.Time `jwt:"exp,omitempty"`
+	NotBefore time.Time `jwt:"nbf,omitempty"`
+	Issued    time.Time `jwt:"iat,omitempty"`
+	ID        string    `jwt:"jti,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// structField is a field of a claims struct and the claim key it maps to.
+type structField struct {
+	index     []int
+	key       string
+	omitEmpty bool
+	// literal is true when the key is taken from a tag, and must not be mapped to a registered claim.
+	literal bool
+}
+
+// structFields returns the claim mapped fields of a struct type.
+//
+// The key is taken from the `jwt` tag, then the `json` tag, then the field name. Tag names are used
+// as is, field names are passed through `Claim.Field()` so untagged fields named after registered
+// claims (eg. `Subject`) map to them.
+// Embedded structs without a name are flattened into the parent.
+func structFields(t reflect.Type, index []int) []structField {
+	fields := []structField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, ok := f.Tag.Lookup("jwt")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, structFields(f.Type, fieldIndex)...)
+
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := name
+		if key == "" {
+			key = Claim{Key: f.Name}.Field()
+		}
+
+		fields = append(fields, structField{
+			index:     fieldIndex,
+			key:       key,
+			literal:   name != "",
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+
+	return fields
+}
+
+// structValue dereferences v and returns it if it is a struct.
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, ErrInvalidStruct
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, ErrInvalidStruct
+	}
+
+	return rv, nil
+}
+
+// ClaimsFromStruct takes a struct (or pointer to a struct) and returns a slice of `Claim`s,
+// one for each exported field, or an error if it is not a struct.
+//
+// Field keys are taken from `jwt` or `json` tags, `-` skips a field and `omitempty` skips zero values.
+// Zero `time.Time` fields are always skipped.
+func ClaimsFromStruct(v interface{}) ([]Claim, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []Claim{}
+
+	for _, f := range structFields(rv.Type(), nil) {
+		fv := rv.FieldByIndex(f.index)
+
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+
+			fv = fv.Elem()
+		}
+
+		// a zero time can not be represented as a NumericDate, so it is always omitted
+		if (f.omitEmpty || fv.Type() == timeType) && fv.IsZero() {
+			continue
+		}
+
+		if f.key == Audience {
+			switch val := fv.Interface().(type) {
+			case string:
+				claims = append(claims, String(Audience, val))
+			case []string:
+				for _, a := range val {
+					claims = append(claims, String(Audience, a))
+				}
+			default:
+				return nil, fmt.Errorf("%w: %s", ErrInvalidClaimType, f.key)
+			}
+
+			continue
+		}
+
+		claim := Any(f.key, fv.Interface())
+		claim.literal = f.literal
+
+		claims = append(claims, claim)
+	}
+
+	return claims, nil
+}
+
+// Decode unmarshals the verified claims into the supplied struct pointer, using the same
+// field mapping as `ClaimsFromStruct`. Claims not present in the token leave the field untouched.
+func (r VerifyResult) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrInvalidStruct
+	}
+
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range structFields(rv.Type(), nil) {
+		claims, ok := r.Claims[f.key]
+		if !ok || len(claims) == 0 {
+			continue
+		}
+
+		fv := rv.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			fv = fv.Elem()
+		}
+
+		if err := decodeClaims(fv, claims); err != nil {
+			return fmt.Errorf("%w: %s", err, f.key)
+		}
+	}
+
+	return nil
+}
+
+// decodeClaims sets the value of a struct field from the claims for its key.
+func decodeClaims(fv reflect.Value, claims []Claim) error {
+	switch fv.Type() {
+	case timeType:
+		t, err := claims[0].AsTime()
+		if err != nil {
+			return err
+		}
+
+		fv.Set(reflect.ValueOf(t))
+
+		return nil
+	case durationType:
+		d, err := claims[0].AsDuration()
+		if err != nil {
+			return err
+		}
+
+		fv.Set(reflect.ValueOf(d))
+
+		return nil
+	case bytesType:
+		b, err := claims[0].AsBinary()
+		if err != nil {
+			return err
+		}
+
+		fv.SetBytes(b)
+
+		return nil
+	}
+
+	var val interface{} = claimValue(claims[0])
+
+	if len(claims) > 1 || (fv.Kind() == reflect.Slice && claims[0].Type == StringType) {
+		vals := make([]interface{}, 0, len(claims))
+		for _, c := range claims {
+			vals = append(vals, claimValue(c))
+		}
+
+		val = vals
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidClaimType, err)
+	}
+
+	if err := json.Unmarshal(data, fv.Addr().Interface()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidClaimType, err)
+	}
+
+	return nil
+}
+
+// claimValue returns the JSON compatible value of a `Claim`.
+func claimValue(c Claim) interface{} {
+	switch c.Type {
+	case StringType:
+		return c.String
+	case Float64Type, Float32Type:
+		return c.Float
+	case Int64Type, Int32Type, Int16Type, Int8Type:
+		return c.Integer
+	case Uint64Type, Uint32Type, Uint16Type, Uint8Type:
+		return c.Uinteger
+	case TimeType:
+		t, _ := c.AsTime()
+
+		return jwt.NewNumericTime(t)
+	case DurationType:
+		d, _ := c.AsDuration()
+
+		return d.Seconds()
+	case BinaryType:
+		b, _ := c.AsBinary()
+
+		return base64.RawURLEncoding.EncodeToString(b)
+	case StringerType, ErrorType:
+		s, err := c.AsString()
+		if err != nil {
+			return nil
+		}
+
+		return s
+	default:
+		return c.Interface
+	}
+}
+
+// SignStruct takes a signer and a claims struct and produces a signed token.
+// See `ClaimsFromStruct` for the field mapping.
+func SignStruct(signer Signer, v interface{}) ([]byte, error) {
+	claims, err := ClaimsFromStruct(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.SignClaims(claims...)
+}
+
+// VerifyStruct takes a verifier and a token and, if it is valid, decodes the claims into the
+// supplied struct pointer. See `VerifyResult.Decode` for the field mapping.
+func VerifyStruct(verifier Verifier, token []byte, v interface{}) (VerifyResult, error) {
+	result, err := verifier.Verify(token)
+	if err != nil {
+		return result, err
+	}
+
+	return result, result.Decode(v)
+}
@@ -0,0 +1,171 @@
+package jwt_test
+
+import (
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type testClaims struct {
+	jwt.RegisteredClaims
+
+	Tenant   string        `jwt:"tenant"`
+	Admin    bool          `json:"admin"`
+	Count    int           `json:"count,omitempty"`
+	Scopes   []string      `json:"scopes"`
+	Lifetime time.Duration `json:"lifetime"`
+	Login    time.Time     `json:"login"`
+	Address  testAddress   `json:"address"`
+	Ignored  string        `json:"-"`
+	internal string
+}
+
+var _ = Describe("JWT Struct Claims", func() {
+	var signer jwt.Signer
+	var verifier jwt.Verifier
+
+	BeforeEach(func() {
+		signer = createSigner()
+		verifier = createVerifier()
+	})
+
+	It("should round trip a claims struct", func() {
+		login := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		expires := time.Now().Add(time.Hour).Round(time.Second).UTC()
+
+		in := testClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "subject",
+				Audiences: []string{"audience", "audience2"},
+				Expires:   expires,
+			},
+			Tenant:   "acme",
+			Admin:    true,
+			Scopes:   []string{"orders:read", "orders:write"},
+			Lifetime: time.Minute,
+			Login:    login,
+			Address:  testAddress{Street: "1 Main St", City: "Springfield"},
+			Ignored:  "ignored",
+			internal: "internal",
+		}
+
+		token, err := jwt.SignStruct(signer, &in)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).NotTo(BeEmpty())
+
+		var out testClaims
+		result, err := jwt.VerifyStruct(verifier, token, &out)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+		Expect(result.Claims).NotTo(HaveKey("count"))
+		Expect(result.Claims).NotTo(HaveKey("Ignored"))
+		Expect(result.Claims).NotTo(HaveKey("internal"))
+
+		Expect(out.Subject).To(Equal("subject"))
+		Expect(out.Audiences).To(ConsistOf("audience", "audience2"))
+		Expect(out.Expires).To(BeTemporally("==", expires))
+		Expect(out.ID).To(Equal(result.ID))
+		Expect(out.Tenant).To(Equal("acme"))
+		Expect(out.Admin).To(BeTrue())
+		Expect(out.Count).To(BeZero())
+		Expect(out.Scopes).To(Equal([]string{"orders:read", "orders:write"}))
+		Expect(out.Lifetime).To(Equal(time.Minute))
+		Expect(out.Login).To(BeTemporally("==", login))
+		Expect(out.Address).To(Equal(in.Address))
+		Expect(out.Ignored).To(BeEmpty())
+		Expect(out.internal).To(BeEmpty())
+	})
+
+	It("should omit zero time fields", func() {
+		in := testClaims{
+			RegisteredClaims: jwt.RegisteredClaims{Audiences: []string{"audience"}},
+		}
+
+		claims, err := jwt.ClaimsFromStruct(in)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, c := range claims {
+			Expect(c.Key).NotTo(Equal("login"))
+		}
+
+		token, err := jwt.SignStruct(signer, in)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out testClaims
+		result, err := jwt.VerifyStruct(verifier, token, &out)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Claims).NotTo(HaveKey("login"))
+		Expect(out.Login.IsZero()).To(BeTrue())
+	})
+
+	It("should map untagged fields named after registered claims", func() {
+		in := struct {
+			Subject  string
+			Audience string
+		}{
+			Subject:  "subject",
+			Audience: "audience",
+		}
+
+		token, err := jwt.SignStruct(signer, in)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Subject).To(Equal("subject"))
+		Expect(result.Audiences).To(ConsistOf("audience"))
+	})
+
+	It("should use tag names literally", func() {
+		in := struct {
+			Audience string `json:"aud"`
+			UserID   string `json:"id"`
+			Subject  string `jwt:"subject"`
+		}{
+			Audience: "audience",
+			UserID:   "user-42",
+			Subject:  "user",
+		}
+
+		token, err := jwt.SignStruct(signer, in)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.GetString("id")).To(Equal("user-42"))
+		Expect(result.GetString("subject")).To(Equal("user"))
+		Expect(result.ID).NotTo(Equal("user-42"))
+		Expect(result.Subject).To(BeEmpty())
+	})
+
+	It("should fail to decode a mismatched claim type", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Audience, "audience"),
+			jwt.String("admin", "yes"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out testClaims
+		_, err = jwt.VerifyStruct(verifier, token, &out)
+		Expect(err).To(MatchError(jwt.ErrInvalidClaimType))
+	})
+
+	It("should fail with a non-struct value", func() {
+		_, err := jwt.SignStruct(signer, "not a struct")
+		Expect(err).To(MatchError(jwt.ErrInvalidStruct))
+
+		token, err := jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		var out testClaims
+		_, err = jwt.VerifyStruct(verifier, token, out)
+		Expect(err).To(MatchError(jwt.ErrInvalidStruct))
+	})
+})
@@ -0,0 +1,233 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultTokenLifetime is the default lifetime of tokens produced by a `SignerTokenSource`.
+const DefaultTokenLifetime = 5 * time.Minute
+
+// DefaultRenewBefore is the default time before expiry that a `Transport` renews a cached token.
+const DefaultRenewBefore = 30 * time.Second
+
+// DefaultRenewTimeout is the default time a `Transport` waits for a token renewal.
+const DefaultRenewTimeout = 30 * time.Second
+
+// TokenSource produces tokens for outgoing requests.
+type TokenSource interface {
+	// Token returns a token for the audience and the time it expires, a zero expiry time never expires.
+	Token(ctx context.Context, audience string) ([]byte, time.Time, error)
+}
+
+// TokenSourceFunc is an adapter to allow the use of ordinary functions as a `TokenSource`.
+type TokenSourceFunc func(ctx context.Context, audience string) ([]byte, time.Time, error)
+
+// Token calls f(ctx, audience).
+func (f TokenSourceFunc) Token(ctx context.Context, audience string) ([]byte, time.Time, error) {
+	return f(ctx, audience)
+}
+
+// SignerTokenSource implements the `TokenSource` interface and signs a new token for each call.
+type SignerTokenSource struct {
+	// Signer produces the tokens.
+	Signer Signer
+	// Subject is the subject of the tokens.
+	Subject string
+	// Claims are additional claims added to the tokens.
+	Claims []Claim
+	// Lifetime is the lifetime of the tokens, it defaults to `DefaultTokenLifetime`.
+	Lifetime time.Duration
+	// Clock provides the nbf and exp claims of the tokens, it defaults to the wall clock.
+	Clock Clock
+}
+
+// Token returns a newly signed token for the audience.
+func (s *SignerTokenSource) Token(ctx context.Context, audience string) ([]byte, time.Time, error) {
+	lifetime := s.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultTokenLifetime
+	}
+
+	now := clockOrSystem(s.Clock).Now()
+	expires := now.Add(lifetime)
+
+	claims := append([]Claim{
+		String(Subject, s.Subject),
+		String(Audience, audience),
+		Time(NotBefore, now),
+		Time(Expires, expires),
+	}, s.Claims...)
+
+	token, err := s.Signer.SignClaims(claims...)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return token, expires, nil
+}
+
+// Transport implements the `http.RoundTripper` interface and attaches a bearer token for the
+// target of each request. Tokens are cached per audience until shortly before they expire,
+// and concurrent requests for the same audience share a single renewal.
+type Transport struct {
+	// Base is the transport used to make requests, it defaults to `http.DefaultTransport`.
+	Base http.RoundTripper
+	// Source produces the tokens.
+	Source TokenSource
+	// Audience returns the audience of the token for a request, it defaults to `AudienceFromURL`.
+	Audience func(r *http.Request) string
+	// RenewBefore is the time before expiry that cached tokens are renewed, it defaults to `DefaultRenewBefore`.
+	RenewBefore time.Duration
+	// RenewTimeout bounds the time a renewal may take, as it is shared it is not bounded by the
+	// request that started it, it defaults to `DefaultRenewTimeout`.
+	RenewTimeout time.Duration
+	// Clock is used to expire cached tokens, it defaults to the wall clock.
+	Clock Clock
+
+	mu     sync.Mutex
+	tokens map[string]*transportToken
+}
+
+// transportToken is a cached token, done is closed once the token has been produced.
+type transportToken struct {
+	done    chan struct{}
+	token   []byte
+	expires time.Time
+	err     error
+}
+
+// AudienceFromURL returns the scheme and host of the request URL as the audience, eg. `https://api.example.com`.
+func AudienceFromURL(r *http.Request) string {
+	u := url.URL{Scheme: r.URL.Scheme, Host: r.URL.Host}
+
+	return u.String()
+}
+
+// RoundTrip attaches a token to a copy of the request and executes it with the base transport.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	audience := AudienceFromURL
+	if t.Audience != nil {
+		audience = t.Audience
+	}
+
+	token, err := t.token(r.Context(), audience(r))
+	if err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	req := r.Clone(r.Context())
+	req.Header.Set("Authorization", "Bearer "+string(token))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(req)
+}
+
+// token returns a cached token for the audience, or waits for a renewal.
+func (t *Transport) token(ctx context.Context, audience string) ([]byte, error) {
+	t.mu.Lock()
+
+	if t.tokens == nil {
+		t.tokens = map[string]*transportToken{}
+	}
+
+	cached, ok := t.tokens[audience]
+	if !ok || t.expired(cached) {
+		cached = &transportToken{done: make(chan struct{})}
+		t.tokens[audience] = cached
+		t.mu.Unlock()
+
+		// the renewal is shared, so it must not fail when the request that started it is cancelled
+		go t.renew(detachedContext{ctx}, audience, cached)
+	} else {
+		t.mu.Unlock()
+	}
+
+	select {
+	case <-cached.done:
+		return cached.token, cached.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// renew produces a new token for the cached entry, failed entries are removed so the next request retries.
+func (t *Transport) renew(ctx context.Context, audience string, cached *transportToken) {
+	defer close(cached.done)
+
+	timeout := t.RenewTimeout
+	if timeout <= 0 {
+		timeout = DefaultRenewTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cached.token, cached.expires, cached.err = t.Source.Token(ctx, audience)
+	if cached.err == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tokens[audience] == cached {
+		delete(t.tokens, audience)
+	}
+}
+
+// expired returns true if a produced token is due for renewal, it must be called with the lock held.
+func (t *Transport) expired(cached *transportToken) bool {
+	select {
+	case <-cached.done:
+	default:
+		return false
+	}
+
+	if cached.err != nil {
+		return true
+	}
+
+	if cached.expires.IsZero() {
+		return false
+	}
+
+	renewBefore := t.RenewBefore
+	if renewBefore <= 0 {
+		renewBefore = DefaultRenewBefore
+	}
+
+	return !clockOrSystem(t.Clock).Now().Before(cached.expires.Add(-renewBefore))
+}
+
+// detachedContext is a context that carries the values of its parent, but is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
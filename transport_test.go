@@ -0,0 +1,255 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/koshatul/jwt/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// waitingContext counts the calls to Done, which the transport makes while a request waits for a token.
+type waitingContext struct {
+	context.Context
+	waiting *int32
+}
+
+func (c waitingContext) Done() <-chan struct{} {
+	atomic.AddInt32(c.waiting, 1)
+
+	return c.Context.Done()
+}
+
+var _ = Describe("JWT Transport", func() {
+	var clock *jwt.FakeClock
+	var verifier *jwt.RSAVerifier
+	var server *httptest.Server
+	var transport *jwt.Transport
+	var client *http.Client
+	var calls int32
+
+	get := func(path string) (int, string) {
+		resp, err := client.Get(server.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer resp.Body.Close()
+
+		return resp.StatusCode, resp.Header.Get("X-Token")
+	}
+
+	BeforeEach(func() {
+		clock = jwt.NewFakeClock(time.Now().Round(time.Second))
+		verifier = createRSAVerifier()
+		verifier.Clock = clock
+		calls = 0
+
+		server = httptest.NewServer((&jwt.Middleware{Verifier: verifier}).Handler(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Token", strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			}),
+		))
+		verifier.Audience = server.URL
+
+		source := &jwt.SignerTokenSource{
+			Signer:   createSigner(),
+			Subject:  "service",
+			Lifetime: time.Minute,
+			Clock:    clock,
+		}
+
+		transport = &jwt.Transport{
+			Source: jwt.TokenSourceFunc(func(ctx context.Context, audience string) ([]byte, time.Time, error) {
+				atomic.AddInt32(&calls, 1)
+
+				return source.Token(ctx, audience)
+			}),
+			Clock: clock,
+		}
+		client = &http.Client{Transport: transport}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should attach a token for the target audience", func() {
+		status, _ := get("/")
+		Expect(status).To(Equal(http.StatusOK))
+	})
+
+	It("should cache the token until shortly before it expires", func() {
+		_, first := get("/")
+		_, second := get("/other")
+		Expect(second).To(Equal(first))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+
+		clock.Advance(time.Minute - jwt.DefaultRenewBefore)
+
+		status, third := get("/")
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(third).NotTo(Equal(first))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(2)))
+	})
+
+	It("should sign a token per audience", func() {
+		audience := "https://one.example.com"
+		transport.Audience = func(r *http.Request) string {
+			return audience
+		}
+
+		verifier.Audience = "https://one.example.com"
+		_, first := get("/")
+
+		audience = "https://two.example.com"
+		verifier.Audience = "https://two.example.com"
+		status, second := get("/")
+
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(second).NotTo(Equal(first))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(2)))
+	})
+
+	It("should share a single renewal between concurrent requests", func() {
+		release := make(chan struct{})
+		source := transport.Source
+
+		transport.Source = jwt.TokenSourceFunc(func(ctx context.Context, audience string) ([]byte, time.Time, error) {
+			<-release
+
+			return source.Token(ctx, audience)
+		})
+
+		var wg sync.WaitGroup
+		var waiting int32
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				ctx := waitingContext{Context: context.Background(), waiting: &waiting}
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := transport.RoundTrip(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				defer resp.Body.Close()
+
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}()
+		}
+
+		// every request is waiting for the renewal before it is released
+		Eventually(func() int32 { return atomic.LoadInt32(&waiting) }).Should(Equal(int32(10)))
+
+		close(release)
+		wg.Wait()
+
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("should bound the time a renewal may take", func() {
+		source := transport.Source
+		blocked := true
+
+		transport.RenewTimeout = 10 * time.Millisecond
+		transport.Source = jwt.TokenSourceFunc(func(ctx context.Context, audience string) ([]byte, time.Time, error) {
+			if blocked {
+				<-ctx.Done()
+
+				return nil, time.Time{}, ctx.Err()
+			}
+
+			return source.Token(ctx, audience)
+		})
+
+		_, err := client.Get(server.URL)
+		Expect(err).To(MatchError(ContainSubstring(context.DeadlineExceeded.Error())))
+
+		blocked = false
+
+		status, _ := get("/")
+		Expect(status).To(Equal(http.StatusOK))
+	})
+
+	It("should not fail a shared renewal when the request that started it is cancelled", func() {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		source := transport.Source
+
+		transport.Source = jwt.TokenSourceFunc(func(ctx context.Context, audience string) ([]byte, time.Time, error) {
+			close(started)
+
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, time.Time{}, ctx.Err()
+			}
+
+			return source.Token(ctx, audience)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cancelled := make(chan error, 1)
+
+		go func() {
+			resp, err := client.Do(req)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+
+			cancelled <- err
+		}()
+
+		<-started
+
+		waiter := make(chan int, 1)
+
+		go func() {
+			defer GinkgoRecover()
+
+			status, _ := get("/")
+			waiter <- status
+		}()
+
+		cancel()
+		Eventually(cancelled).Should(Receive(MatchError(ContainSubstring("context canceled"))))
+
+		close(release)
+		Eventually(waiter).Should(Receive(Equal(http.StatusOK)))
+	})
+
+	It("should retry after a failed renewal", func() {
+		failing := true
+		source := transport.Source
+
+		transport.Source = jwt.TokenSourceFunc(func(ctx context.Context, audience string) ([]byte, time.Time, error) {
+			if failing {
+				return nil, time.Time{}, errors.New("signer unavailable")
+			}
+
+			return source.Token(ctx, audience)
+		})
+
+		_, err := client.Get(server.URL)
+		Expect(err).To(MatchError(ContainSubstring("signer unavailable")))
+
+		failing = false
+
+		status, _ := get("/")
+		Expect(status).To(Equal(http.StatusOK))
+	})
+})
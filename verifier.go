@@ -1,8 +1,12 @@
 package jwt
 
 import (
+	"context"
 	"crypto/rsa"
 	"errors"
+	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/pascaldekloe/jwt"
@@ -11,9 +15,34 @@ import (
 // ErrTokenInvalidAudience is the error returned when an audience does not match the token.
 var ErrTokenInvalidAudience = errors.New("invalid token audience")
 
+// ErrAudiencePatternInvalid is the error returned when one of the verifier's `AudiencePatterns` is malformed.
+var ErrAudiencePatternInvalid = errors.New("invalid audience pattern")
+
 // ErrTokenTimeNotValid is the general error returned when a token is outside the NotBefore or Expires times.
 var ErrTokenTimeNotValid = errors.New("token time is not valid")
 
+// ErrTokenExpired is the error returned when a token is past its Expires time, it also matches `ErrTokenTimeNotValid`.
+var ErrTokenExpired error = &timeNotValidError{"token has expired"}
+
+// ErrTokenNotYetValid is the error returned when a token is before its NotBefore time, it also matches
+// `ErrTokenTimeNotValid`.
+var ErrTokenNotYetValid error = &timeNotValidError{"token is not valid yet"}
+
+// ErrTokenExpiryRequired is the error returned when a token has no exp claim and the verifier requires one.
+var ErrTokenExpiryRequired = errors.New("token expiry required")
+
+// ErrTokenIssuedRequired is the error returned when a token has no iat claim and the verifier requires one.
+var ErrTokenIssuedRequired = errors.New("token issued time required")
+
+// ErrTokenLifetimeExceeded is the error returned when a token's lifetime is longer than the verifier allows.
+var ErrTokenLifetimeExceeded = errors.New("token lifetime exceeds maximum")
+
+// ErrTokenIssuedInFuture is the error returned when a token's iat claim is in the future.
+var ErrTokenIssuedInFuture = errors.New("token issued in the future")
+
+// ErrTokenTooOld is the error returned when a token was issued longer ago than the verifier allows.
+var ErrTokenTooOld = errors.New("token exceeds maximum age")
+
 // VerifyResult returns the information about the token verification.
 type VerifyResult struct {
 	ID          string
@@ -24,6 +53,101 @@ type VerifyResult struct {
 	NotBefore   time.Time
 	Expires     time.Time
 	Claims      map[string][]Claim
+	// Audience is the token audience that was accepted by the verifier.
+	Audience string
+	// InLeeway is true when the token was only accepted because of the verifier's leeway.
+	InLeeway bool
+}
+
+// Claim returns the first claim with the supplied key, or `ErrClaimNotFound` if it is not present.
+func (r VerifyResult) Claim(key string) (Claim, error) {
+	if c, ok := r.Claims[key]; ok && len(c) > 0 {
+		return c[0], nil
+	}
+
+	return Claim{}, fmt.Errorf("%w: %s", ErrClaimNotFound, key)
+}
+
+// GetString returns the string value of the claim with the supplied key.
+func (r VerifyResult) GetString(key string) (string, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return "", err
+	}
+
+	return c.AsString()
+}
+
+// GetInt64 returns the integer value of the claim with the supplied key.
+func (r VerifyResult) GetInt64(key string) (int64, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.AsInt64()
+}
+
+// GetFloat64 returns the numeric value of the claim with the supplied key.
+func (r VerifyResult) GetFloat64(key string) (float64, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.AsFloat64()
+}
+
+// GetBool returns the boolean value of the claim with the supplied key.
+func (r VerifyResult) GetBool(key string) (bool, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return false, err
+	}
+
+	return c.AsBool()
+}
+
+// GetTime returns the time value of the claim with the supplied key.
+func (r VerifyResult) GetTime(key string) (time.Time, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return c.AsTime()
+}
+
+// GetStrings returns the values of all claims with the supplied key as a slice of strings,
+// flattening array claims (eg. multiple audiences are returned as a single slice).
+func (r VerifyResult) GetStrings(key string) ([]string, error) {
+	claims, ok := r.Claims[key]
+	if !ok || len(claims) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrClaimNotFound, key)
+	}
+
+	s := []string{}
+
+	for _, c := range claims {
+		v, err := c.AsStrings()
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, v...)
+	}
+
+	return s, nil
+}
+
+// GetMap returns the object value of the claim with the supplied key.
+func (r VerifyResult) GetMap(key string) (map[string]interface{}, error) {
+	c, err := r.Claim(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.AsMap()
 }
 
 // Verifier takes a token and returns the subject if it is valid, or an error if it is not.
@@ -37,6 +161,52 @@ type RSAVerifier struct {
 	PublicKey *rsa.PublicKey
 	Issuer    string
 	Audience  string
+	// Audiences are additional audiences accepted by the verifier.
+	Audiences []string
+	// AudiencePatterns are glob patterns (see `path.Match`) of accepted audiences, eg. `https://*.api.example.com`.
+	// A malformed pattern rejects every token with `ErrAudiencePatternInvalid`.
+	AudiencePatterns []string
+	// AudiencePrefixes are URL prefixes of accepted audiences, eg. `https://api.example.com/` accepts
+	// `https://api.example.com/orders`. Prefixes only match on a path boundary.
+	AudiencePrefixes []string
+	// Schema declares the expected types of unregistered claims, see `ClaimSchema`.
+	Schema *ClaimSchema
+	// Rules are run against the result once the signature, claim type, audience and time checks have passed.
+	Rules []Rule
+	// Leeway is the tolerance for clock skew between hosts applied when checking the exp, nbf and iat claims.
+	Leeway time.Duration
+	// RequireExpiry rejects tokens without an exp claim.
+	RequireExpiry bool
+	// RequireIssued rejects tokens without an iat claim.
+	RequireIssued bool
+	// RejectFutureIssued rejects tokens with an iat claim in the future.
+	RejectFutureIssued bool
+	// MaxLifetime, when set, rejects tokens where exp - iat (or exp - nbf without an iat claim) is
+	// greater, tokens must have an exp claim and either an iat or nbf claim.
+	MaxLifetime time.Duration
+	// MaxAge, when set, rejects tokens issued longer ago, tokens must have an iat claim.
+	MaxAge time.Duration
+	// Clock provides the time tokens are verified at, it defaults to the wall clock.
+	Clock Clock
+	// CertificateBound requires tokens to have a cnf claim matching the client certificate of the
+	// TLS connection state in the context, see `ContextWithConnectionState`.
+	CertificateBound bool
+	// DPoP, when set, requires tokens to have a cnf claim matching the key of a valid DPoP proof
+	// in the context, see `ContextWithDPoPProof`. The proof is checked once all other checks have passed.
+	DPoP *DPoPVerifier
+	// Revocations, when set, is checked for the token ID once all other checks have passed, tokens
+	// without an ID are rejected.
+	Revocations RevocationStore
+	// IssuedBefore, when set, is checked for subject and session revocations once all other checks
+	// have passed, tokens must have an iat claim.
+	IssuedBefore IssuedBeforeStore
+	// Introspection, when set, is called for online tokens (the onl claim) once all other checks
+	// have passed, tokens the issuer reports as inactive are rejected.
+	Introspection Introspector
+	// Replay, when set, makes tokens one-time use. The token ID is recorded until the token expires
+	// plus the leeway once all other checks have passed, and tokens without an ID or exp claim are
+	// rejected.
+	Replay ReplayStore
 	// Algorithms []string
 }
 
@@ -55,7 +225,9 @@ func NewRSAVerifierFromFile(audience, filename string) (Verifier, error) {
 	}, nil
 }
 
-func (v *RSAVerifier) getClaimMapFromClaims(claims *jwt.Claims) map[string][]Claim {
+// getClaimMapFromClaims returns the claims of the token as a map, claims that do not match the
+// verifier's `ClaimSchema` are omitted and recorded in the `ValidationError`.
+func (v *RSAVerifier) getClaimMapFromClaims(claims *jwt.Claims, verr *ValidationError) map[string][]Claim {
 	c := make(map[string][]Claim)
 
 	if claims.Issuer != "" {
@@ -90,8 +262,15 @@ func (v *RSAVerifier) getClaimMapFromClaims(claims *jwt.Claims) map[string][]Cla
 	c[Audience] = aud
 
 	// non standard claims
-	for k, v := range claims.Set {
-		c[k] = []Claim{Any(k, v)}
+	for k, val := range claims.Set {
+		claim, err := v.Schema.Decode(k, val)
+		if err != nil {
+			verr.add(ValidationErrorClaimType, err)
+
+			continue
+		}
+
+		c[k] = []Claim{claim}
 	}
 
 	return c
@@ -99,29 +278,42 @@ func (v *RSAVerifier) getClaimMapFromClaims(claims *jwt.Claims) map[string][]Cla
 
 // Verify takes the token and checks it's signature against the RSA public key,
 // and the audience, notbefore and expires validity.
+//
+// Errors are returned as a `*ValidationError` containing every failed check.
 func (v *RSAVerifier) Verify(token []byte) (VerifyResult, error) {
-	checkTime := time.Now()
-	result := VerifyResult{}
+	return v.VerifyContext(context.Background(), token)
+}
 
-	claims, err := jwt.RSACheck(token, v.PublicKey)
-	if err != nil {
-		return result, err
-	}
+// VerifyContext is the same as Verify, but passes the context through to the verifier's hooks.
+func (v *RSAVerifier) VerifyContext(ctx context.Context, token []byte) (VerifyResult, error) {
+	return v.verify(ctx, token, clockOrSystem(v.Clock).Now())
+}
 
-	if !matchAudience(claims, v.Audience) {
-		return result, ErrTokenInvalidAudience
+// VerifyAt is the same as Verify, but checks the time validity of the token as of the
+// supplied time instead of the verifier's clock (eg. to replay historic tokens).
+func (v *RSAVerifier) VerifyAt(token []byte, checkTime time.Time) (VerifyResult, error) {
+	return v.verify(context.Background(), token, checkTime)
+}
+
+func (v *RSAVerifier) verify(ctx context.Context, token []byte, checkTime time.Time) (VerifyResult, error) {
+	if err := ctx.Err(); err != nil {
+		return VerifyResult{}, err
 	}
 
-	if !claims.Valid(checkTime) {
-		return result, ErrTokenTimeNotValid
+	claims, err := jwt.RSACheck(token, v.PublicKey)
+	if err != nil {
+		return VerifyResult{}, newParseError(err)
 	}
 
-	result = VerifyResult{
+	verr := &ValidationError{}
+
+	result := VerifyResult{
 		Subject:   claims.Subject,
 		ID:        claims.ID,
 		NotBefore: claims.NotBefore.Time(),
 		Expires:   claims.Expires.Time(),
 		Audiences: claims.Audiences,
+		Claims:    v.getClaimMapFromClaims(claims, verr),
 	}
 
 	if val, ok := claims.Set["onl"].(bool); ok {
@@ -129,14 +321,263 @@ func (v *RSAVerifier) Verify(token []byte) (VerifyResult, error) {
 	}
 
 	result.Fingerprint, _ = claims.String("fpt")
-	result.Claims = v.getClaimMapFromClaims(claims)
+
+	if err := v.checkAudiencePatterns(); err != nil {
+		verr.add(ValidationErrorAudience, err)
+	}
+
+	if aud, ok := v.matchAudience(claims); ok {
+		result.Audience = aud
+	} else {
+		verr.add(ValidationErrorAudience, ErrTokenInvalidAudience)
+	}
+
+	inLeeway := v.checkTime(claims, checkTime, verr)
+	if v.checkTimePolicies(claims, checkTime, verr) {
+		inLeeway = true
+	}
+
+	result.InLeeway = inLeeway
+
+	if verr.Flags == 0 {
+		for _, rule := range v.Rules {
+			if err := validateRule(ctx, rule, result); err != nil {
+				verr.add(ValidationErrorClaim, err)
+			}
+		}
+	}
+
+	if v.CertificateBound {
+		state, _ := ConnectionStateFromContext(ctx)
+		if err := VerifyCertificateBinding(result, state); err != nil {
+			verr.add(ValidationErrorBinding, err)
+		}
+	}
+
+	if verr.Flags == 0 {
+		v.checkRevocation(ctx, claims, verr)
+	}
+
+	if verr.Flags == 0 && result.IsOnline {
+		v.checkIntrospection(ctx, token, verr)
+	}
+
+	if verr.Flags == 0 && v.DPoP != nil {
+		v.checkDPoP(ctx, token, result, verr)
+	}
+
+	if verr.Flags == 0 {
+		v.checkReplay(ctx, claims, verr)
+	}
+
+	if verr.Flags != 0 {
+		verr.Result = result
+
+		return VerifyResult{}, verr
+	}
 
 	return result, nil
 }
 
-func matchAudience(c *jwt.Claims, want string) bool {
+// checkIntrospection records an error if the issuer reports the token as inactive.
+func (v *RSAVerifier) checkIntrospection(ctx context.Context, token []byte, verr *ValidationError) {
+	if v.Introspection == nil {
+		return
+	}
+
+	active, err := v.Introspection.Introspect(ctx, token)
+	if err != nil {
+		verr.add(ValidationErrorLookup, fmt.Errorf("introspection failed: %w", err))
+	} else if !active {
+		verr.add(ValidationErrorRevoked, ErrTokenInactive)
+	}
+}
+
+// checkDPoP records an error if the DPoP proof in the context is not valid for the token.
+func (v *RSAVerifier) checkDPoP(ctx context.Context, token []byte, result VerifyResult, verr *ValidationError) {
+	req, ok := dpopRequestFromContext(ctx)
+	if !ok {
+		verr.add(ValidationErrorBinding, fmt.Errorf("%w: proof is required", ErrDPoPProofInvalid))
+
+		return
+	}
+
+	proof, err := v.DPoP.VerifyProof(ctx, req.proof, req.method, req.uri, token)
+
+	switch {
+	case err == nil:
+		if err := VerifyDPoPBinding(result, proof); err != nil {
+			verr.add(ValidationErrorBinding, err)
+		}
+	case errors.Is(err, ErrDPoPProofReplayed):
+		verr.add(ValidationErrorReplayed, err)
+	case errors.Is(err, ErrDPoPProofInvalid):
+		verr.add(ValidationErrorBinding, err)
+	default:
+		verr.add(ValidationErrorLookup, err)
+	}
+}
+
+// checkReplay records the token ID in the replay store, and records an error if it has already been used.
+func (v *RSAVerifier) checkReplay(ctx context.Context, claims *jwt.Claims, verr *ValidationError) {
+	if v.Replay == nil {
+		return
+	}
+
+	if claims.ID == "" {
+		verr.add(ValidationErrorPolicy, ErrTokenIDRequired)
+
+		return
+	}
+
+	// the token is accepted until it expires plus the leeway, so it must be recorded until then
+	first, err := v.Replay.Use(ctx, claims.ID, claims.Expires.Time().Add(v.Leeway))
+	if err != nil {
+		verr.add(ValidationErrorLookup, fmt.Errorf("replay lookup failed: %w", err))
+	} else if !first {
+		verr.add(ValidationErrorReplayed, ErrTokenReplayed)
+	}
+}
+
+// checkRevocation records an error if the token ID, or the subject or session the token
+// was issued for, has been revoked.
+func (v *RSAVerifier) checkRevocation(ctx context.Context, claims *jwt.Claims, verr *ValidationError) {
+	if v.Revocations != nil {
+		if claims.ID == "" {
+			verr.add(ValidationErrorPolicy, ErrTokenIDRequired)
+		} else if revoked, err := v.Revocations.IsRevoked(ctx, claims.ID); err != nil {
+			verr.add(ValidationErrorLookup, fmt.Errorf("revocation lookup failed: %w", err))
+		} else if revoked {
+			verr.add(ValidationErrorRevoked, ErrTokenRevoked)
+		}
+	}
+
+	if v.IssuedBefore != nil && claims.Issued != nil {
+		session, _ := claims.String(SessionID)
+
+		before, err := v.IssuedBefore.RevokedBefore(ctx, claims.Subject, session)
+		if err != nil {
+			verr.add(ValidationErrorLookup, fmt.Errorf("revocation lookup failed: %w", err))
+		} else if claims.Issued.Time().Before(before) {
+			verr.add(ValidationErrorRevoked, ErrTokenRevoked)
+		}
+	}
+}
+
+// checkTime records an error if the token is not valid at the supplied time allowing for the leeway,
+// and returns whether it was only valid because of the leeway.
+func (v *RSAVerifier) checkTime(claims *jwt.Claims, t time.Time, verr *ValidationError) (inLeeway bool) {
+	if claims.Expires != nil {
+		exp := claims.Expires.Time()
+		if !exp.After(t.Add(-v.Leeway)) {
+			verr.add(ValidationErrorExpired, &TokenTimeError{Err: ErrTokenExpired, Time: exp, CheckTime: t, Leeway: v.Leeway})
+		}
+
+		inLeeway = inLeeway || !exp.After(t)
+	}
+
+	if claims.NotBefore != nil {
+		nbf := claims.NotBefore.Time()
+		if nbf.After(t.Add(v.Leeway)) {
+			verr.add(ValidationErrorNotValidYet, &TokenTimeError{Err: ErrTokenNotYetValid, Time: nbf, CheckTime: t, Leeway: v.Leeway})
+		}
+
+		inLeeway = inLeeway || nbf.After(t)
+	}
+
+	return inLeeway
+}
+
+// checkTimePolicies records an error for each expiry, issued and lifetime policy of the verifier
+// the token does not satisfy at the supplied time, and returns whether the iat claim was only
+// accepted because of the leeway.
+func (v *RSAVerifier) checkTimePolicies(claims *jwt.Claims, t time.Time, verr *ValidationError) (inLeeway bool) {
+	if claims.Expires == nil && (v.RequireExpiry || v.MaxLifetime > 0 || v.Replay != nil) {
+		verr.add(ValidationErrorPolicy, ErrTokenExpiryRequired)
+	}
+
+	if claims.Issued == nil && (v.RequireIssued || v.MaxAge > 0 || v.IssuedBefore != nil) {
+		verr.add(ValidationErrorPolicy, ErrTokenIssuedRequired)
+	}
+
+	if v.MaxLifetime > 0 && claims.Expires != nil {
+		start := claims.Issued
+		if start == nil {
+			start = claims.NotBefore
+		}
+
+		if start == nil {
+			verr.add(ValidationErrorPolicy, ErrTokenIssuedRequired)
+		} else if claims.Expires.Time().Sub(start.Time()) > v.MaxLifetime {
+			verr.add(ValidationErrorPolicy, ErrTokenLifetimeExceeded)
+		}
+	}
+
+	if claims.Issued != nil {
+		iat := claims.Issued.Time()
+
+		if v.RejectFutureIssued {
+			if iat.After(t.Add(v.Leeway)) {
+				verr.add(ValidationErrorPolicy, ErrTokenIssuedInFuture)
+			}
+
+			inLeeway = inLeeway || iat.After(t)
+		}
+
+		if v.MaxAge > 0 {
+			if t.Sub(iat) > v.MaxAge+v.Leeway {
+				verr.add(ValidationErrorPolicy, ErrTokenTooOld)
+			}
+
+			inLeeway = inLeeway || t.Sub(iat) > v.MaxAge
+		}
+	}
+
+	return inLeeway
+}
+
+// checkAudiencePatterns returns an error if any of the audience patterns is malformed.
+func (v *RSAVerifier) checkAudiencePatterns() error {
+	for _, pattern := range v.AudiencePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: %q", ErrAudiencePatternInvalid, pattern)
+		}
+	}
+
+	return nil
+}
+
+// matchAudience returns the first token audience accepted by the verifier.
+func (v *RSAVerifier) matchAudience(c *jwt.Claims) (string, bool) {
 	for _, s := range c.Audiences {
-		if s == want {
+		if s == v.Audience || v.acceptAudience(s) {
+			return s, true
+		}
+	}
+
+	return "", false
+}
+
+// acceptAudience returns true if the audience matches any of the additional audiences, patterns or prefixes.
+func (v *RSAVerifier) acceptAudience(aud string) bool {
+	for _, want := range v.Audiences {
+		if aud == want {
+			return true
+		}
+	}
+
+	for _, pattern := range v.AudiencePatterns {
+		if ok, _ := path.Match(pattern, aud); ok {
+			return true
+		}
+	}
+
+	for _, prefix := range v.AudiencePrefixes {
+		if aud == prefix {
+			return true
+		}
+
+		if strings.HasPrefix(aud, prefix) && (strings.HasSuffix(prefix, "/") || aud[len(prefix)] == '/') {
 			return true
 		}
 	}
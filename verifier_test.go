@@ -69,6 +69,7 @@ var _ = Describe("JWT Verifier", func() {
 		result, err := verifier.Verify(token)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(result.Audiences).To(ContainElement("audience"))
+		Expect(result.Audience).To(Equal("audience"))
 	})
 
 	It("should succeed, with array of claims", func() {
@@ -307,7 +308,7 @@ var _ = Describe("JWT Verifier", func() {
 		Expect(token).NotTo(BeEmpty())
 
 		result, err := verifier.Verify(token)
-		Expect(err).To(Equal(jwt.ErrTokenInvalidAudience))
+		Expect(err).To(MatchError(jwt.ErrTokenInvalidAudience))
 		Expect(result.IsOnline).To(BeFalse())
 		Expect(result.Subject).NotTo(Equal("subject"))
 	})
@@ -325,7 +326,9 @@ var _ = Describe("JWT Verifier", func() {
 		Expect(token).NotTo(BeEmpty())
 
 		result, err := verifier.Verify(token)
-		Expect(err).To(Equal(jwt.ErrTokenTimeNotValid))
+		Expect(err).To(MatchError(jwt.ErrTokenTimeNotValid))
+		Expect(err).To(MatchError(jwt.ErrTokenNotYetValid))
+		Expect(err).NotTo(MatchError(jwt.ErrTokenExpired))
 		Expect(result.IsOnline).To(BeFalse())
 		Expect(result.Subject).NotTo(Equal("subject"))
 	})
@@ -343,7 +346,9 @@ var _ = Describe("JWT Verifier", func() {
 		Expect(token).NotTo(BeEmpty())
 
 		result, err := verifier.Verify(token)
-		Expect(err).To(Equal(jwt.ErrTokenTimeNotValid))
+		Expect(err).To(MatchError(jwt.ErrTokenTimeNotValid))
+		Expect(err).To(MatchError(jwt.ErrTokenExpired))
+		Expect(err).NotTo(MatchError(jwt.ErrTokenNotYetValid))
 		Expect(result.IsOnline).To(BeFalse())
 		Expect(result.Subject).NotTo(Equal("subject"))
 	})
@@ -455,4 +460,219 @@ var _ = Describe("JWT Verifier", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(issuedAt).To(BeTemporally("~", issued, time.Millisecond))
 	})
+
+	It("should return typed claims from the result", func() {
+		token, err := signer.SignClaims(
+			jwt.String(jwt.Subject, "subject"),
+			jwt.String(jwt.Audience, "audience"),
+			jwt.String(jwt.Audience, "audience2"),
+			jwt.String("tenant", "acme"),
+			jwt.Int("count", 42),
+			jwt.Bool("admin", true),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		result, err := verifier.Verify(token)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.GetString("tenant")).To(Equal("acme"))
+		Expect(result.GetInt64("count")).To(Equal(int64(42)))
+		Expect(result.GetFloat64("count")).To(Equal(42.0))
+		Expect(result.GetBool("admin")).To(BeTrue())
+		Expect(result.GetStrings(jwt.Audience)).To(ConsistOf("audience", "audience2"))
+
+		_, err = result.GetString("count")
+		Expect(err).To(MatchError(jwt.ErrInvalidClaimType))
+
+		_, err = result.GetString("missing")
+		Expect(err).To(MatchError(jwt.ErrClaimNotFound))
+	})
+
+	Context("with leeway", func() {
+		var rsaVerifier *jwt.RSAVerifier
+
+		BeforeEach(func() {
+			rsaVerifier = createRSAVerifier()
+			rsaVerifier.Leeway = time.Minute
+		})
+
+		DescribeTable("should accept tokens within the leeway",
+			func(nbf, exp time.Time, inLeeway bool) {
+				token, err := jwt.Sign(signer, "subject", "audience", false, nbf, exp)
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Subject).To(Equal("subject"))
+				Expect(result.InLeeway).To(Equal(inLeeway))
+			},
+			Entry("valid", time.Now().Add(-1*time.Minute), time.Now().Add(time.Hour), false),
+			Entry("expired", time.Now().Add(-1*time.Hour), time.Now().Add(-30*time.Second), true),
+			Entry("not valid yet", time.Now().Add(30*time.Second), time.Now().Add(time.Hour), true),
+		)
+
+		DescribeTable("should reject tokens outside the leeway",
+			func(nbf, exp time.Time) {
+				token, err := jwt.Sign(signer, "subject", "audience", false, nbf, exp)
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).To(MatchError(jwt.ErrTokenTimeNotValid))
+				Expect(result.Subject).To(BeEmpty())
+				Expect(result.InLeeway).To(BeFalse())
+			},
+			Entry("expired", time.Now().Add(-1*time.Hour), time.Now().Add(-2*time.Minute)),
+			Entry("not valid yet", time.Now().Add(2*time.Minute), time.Now().Add(time.Hour)),
+		)
+
+		DescribeTable("should report issued times accepted within the leeway",
+			func(iat time.Time, inLeeway bool) {
+				rsaVerifier.RejectFutureIssued = true
+				rsaVerifier.MaxAge = time.Hour
+
+				token, err := signer.SignClaims(
+					jwt.String(jwt.Audience, "audience"),
+					jwt.Time(jwt.Issued, iat),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.InLeeway).To(Equal(inLeeway))
+			},
+			Entry("valid", time.Now().Add(-1*time.Minute), false),
+			Entry("issued in the future", time.Now().Add(30*time.Second), true),
+			Entry("too old", time.Now().Add(-1*time.Hour-30*time.Second), true),
+		)
+	})
+
+	Context("with time policies", func() {
+		var rsaVerifier *jwt.RSAVerifier
+
+		BeforeEach(func() {
+			rsaVerifier = createRSAVerifier()
+			rsaVerifier.RequireExpiry = true
+			rsaVerifier.RequireIssued = true
+			rsaVerifier.RejectFutureIssued = true
+			rsaVerifier.MaxLifetime = time.Hour
+			rsaVerifier.MaxAge = 30 * time.Minute
+		})
+
+		It("should accept a token satisfying the policies", func() {
+			token, err := signer.SignClaims(
+				jwt.String(jwt.Audience, "audience"),
+				jwt.Time(jwt.Issued, time.Now().Add(-1*time.Minute)),
+				jwt.Time(jwt.Expires, time.Now().Add(30*time.Minute)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rsaVerifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should fall back to the not before time for the lifetime", func() {
+			rsaVerifier.RequireIssued = false
+			rsaVerifier.MaxAge = 0
+
+			token, err := jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(2*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rsaVerifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrTokenLifetimeExceeded))
+		})
+
+		DescribeTable("should reject a token violating a policy",
+			func(expected error, claims ...jwt.Claim) {
+				token, err := signer.SignClaims(append([]jwt.Claim{jwt.String(jwt.Audience, "audience")}, claims...)...)
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).To(MatchError(expected))
+				Expect(result.Audiences).To(BeEmpty())
+			},
+			Entry("missing expiry", jwt.ErrTokenExpiryRequired,
+				jwt.Time(jwt.Issued, time.Now()),
+			),
+			Entry("missing issued", jwt.ErrTokenIssuedRequired,
+				jwt.Time(jwt.Expires, time.Now().Add(time.Minute)),
+			),
+			Entry("lifetime exceeded", jwt.ErrTokenLifetimeExceeded,
+				jwt.Time(jwt.Issued, time.Now()),
+				jwt.Time(jwt.Expires, time.Now().Add(2*time.Hour)),
+			),
+			Entry("issued in the future", jwt.ErrTokenIssuedInFuture,
+				jwt.Time(jwt.Issued, time.Now().Add(time.Minute)),
+				jwt.Time(jwt.Expires, time.Now().Add(time.Hour)),
+			),
+			Entry("too old", jwt.ErrTokenTooOld,
+				jwt.Time(jwt.Issued, time.Now().Add(-45*time.Minute)),
+				jwt.Time(jwt.Expires, time.Now().Add(time.Minute)),
+			),
+		)
+	})
+
+	Context("with multiple audiences", func() {
+		var rsaVerifier *jwt.RSAVerifier
+
+		BeforeEach(func() {
+			rsaVerifier = createRSAVerifier()
+			rsaVerifier.Audiences = []string{"audience2", "audience3"}
+			rsaVerifier.AudiencePatterns = []string{"https://*.api.example.com"}
+			rsaVerifier.AudiencePrefixes = []string{"https://example.com/api"}
+		})
+
+		DescribeTable("should accept a matching audience",
+			func(audience string) {
+				token, err := jwt.Sign(signer, "subject", audience, false, time.Now(), time.Now().Add(time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Audience).To(Equal(audience))
+			},
+			Entry("primary", "audience"),
+			Entry("additional", "audience3"),
+			Entry("pattern", "https://tenant.api.example.com"),
+			Entry("prefix", "https://example.com/api"),
+			Entry("prefix path", "https://example.com/api/orders"),
+		)
+
+		DescribeTable("should reject a non-matching audience",
+			func(audience string) {
+				token, err := jwt.Sign(signer, "subject", audience, false, time.Now(), time.Now().Add(time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+
+				result, err := rsaVerifier.Verify(token)
+				Expect(err).To(MatchError(jwt.ErrTokenInvalidAudience))
+				Expect(result.Audience).To(BeEmpty())
+			},
+			Entry("unknown", "audience4"),
+			Entry("pattern", "https://tenant.api.example.com.evil.com"),
+			Entry("pattern path", "https://evil.com/x.api.example.com"),
+			Entry("prefix", "https://example.com/apiary"),
+		)
+
+		It("should return the matched audience from a token with multiple audiences", func() {
+			token, err := signer.SignClaims(
+				jwt.String(jwt.Audience, "audience4"),
+				jwt.String(jwt.Audience, "audience2"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := rsaVerifier.Verify(token)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Audience).To(Equal("audience2"))
+			Expect(result.Audiences).To(ConsistOf("audience4", "audience2"))
+		})
+
+		It("should reject tokens when an audience pattern is malformed", func() {
+			rsaVerifier.AudiencePatterns = []string{"https://[.api.example.com"}
+
+			token, err := jwt.Sign(signer, "subject", "audience", false, time.Now(), time.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rsaVerifier.Verify(token)
+			Expect(err).To(MatchError(jwt.ErrAudiencePatternInvalid))
+			Expect(err.Error()).To(ContainSubstring("https://[.api.example.com"))
+		})
+	})
 })